@@ -0,0 +1,156 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-15 14:12:57
+ * @Description  : bidirectional sorted map backed by two red-black trees
+ */
+package bidimap
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/Jayj1997/go-common/comparator"
+	"github.com/Jayj1997/go-common/rbt"
+)
+
+// Map is a one-to-one map which looks values up by key and keys up by value,
+// it keeps a forward tree ordered by the key comparator and an inverse tree ordered by the value comparator.
+// Putting a value already mapped to another key moves it to the new key.
+type Map[K, V any] struct {
+	forward *rbt.Tree[K, V]
+	inverse *rbt.Tree[V, K]
+}
+
+// New instantiates a bidirectional map over ordered key and value types
+func New[K, V cmp.Ordered]() *Map[K, V] {
+	return &Map[K, V]{forward: rbt.New[K, V](), inverse: rbt.New[V, K]()}
+}
+
+// NewWithFunc instantiates a bidirectional map with typed compare functions of keys and values
+func NewWithFunc[K, V any](keyCompare func(a, b K) int, valueCompare func(a, b V) int) *Map[K, V] {
+	return &Map[K, V]{forward: rbt.NewWithFunc[K, V](keyCompare), inverse: rbt.NewWithFunc[V, K](valueCompare)}
+}
+
+// NewWith instantiates a bidirectional map with the custom comparators of keys and values,
+// i.e. keys and values are of type interface{}.
+func NewWith(keyComparator, valueComparator comparator.Comparator) *Map[interface{}, interface{}] {
+	return &Map[interface{}, interface{}]{forward: rbt.NewWith(keyComparator), inverse: rbt.NewWith(valueComparator)}
+}
+
+// Put maps key to value, the previous value of key and the previous key of value are dropped.
+// Key and value should adhere to their comparator's type assertion, otherwise method panics
+// before changing the map.
+func (m *Map[K, V]) Put(key K, value V) {
+
+	// assert both types before touching either tree
+	m.forward.Comparator(key, key)
+	m.inverse.Comparator(value, value)
+
+	if old, found := m.forward.Get(key); found {
+		m.inverse.Remove(old)
+	}
+
+	if old, found := m.inverse.Get(value); found {
+		m.forward.Remove(old)
+	}
+
+	m.forward.Insert(key, value)
+	m.inverse.Insert(value, key)
+}
+
+// Get returns the value of key, second return parameter is true if key was found, otherwise false
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Get(key K) (value V, found bool) {
+	return m.forward.Get(key)
+}
+
+// GetKey returns the key of value, second return parameter is true if value was found, otherwise false
+// Value should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) GetKey(value V) (key K, found bool) {
+	return m.inverse.Get(value)
+}
+
+// Remove removes key and its value
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Remove(key K) {
+	if value, found := m.forward.Get(key); found {
+		m.forward.Remove(key)
+		m.inverse.Remove(value)
+	}
+}
+
+// RemoveValue removes value and its key
+// Value should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) RemoveValue(value V) {
+	m.Inverse().Remove(value)
+}
+
+// Contains returns true if key is in the map
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Contains(key K) bool {
+	_, found := m.forward.Get(key)
+	return found
+}
+
+// ContainsValue returns true if value is in the map
+// Value should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) ContainsValue(value V) bool {
+	_, found := m.inverse.Get(value)
+	return found
+}
+
+// Inverse returns the value-to-key view of the map in O(1),
+// both share the same trees so a change through one is seen by the other.
+func (m *Map[K, V]) Inverse() *Map[V, K] {
+	return &Map[V, K]{forward: m.inverse, inverse: m.forward}
+}
+
+// Empty returns true if map does not contain any pairs
+func (m *Map[K, V]) Empty() bool {
+	return m.forward.Empty()
+}
+
+// Size returns number of pairs in the map
+func (m *Map[K, V]) Size() int {
+	return m.forward.Size()
+}
+
+// Keys returns all keys in-order
+func (m *Map[K, V]) Keys() []K {
+	return m.forward.Keys()
+}
+
+// Values returns all values in-order based on the key, see Inverse for values in their own order
+func (m *Map[K, V]) Values() []V {
+	return m.forward.Values()
+}
+
+// Clear removes all pairs from the map
+func (m *Map[K, V]) Clear() {
+	m.forward.Clear()
+	m.inverse.Clear()
+}
+
+// Iterator returns a stateful iterator whose elements are key/value pairs in ascending key order,
+// Inverse().Iterator() walks the pairs in ascending value order.
+func (m *Map[K, V]) Iterator() rbt.Iterator[K, V] {
+	return m.forward.Iterator()
+}
+
+// Each calls fn on every pair in ascending key order until fn returns false
+func (m *Map[K, V]) Each(fn func(key K, value V) bool) {
+	m.forward.Each(fn)
+}
+
+// String returns a string representation of container
+func (m *Map[K, V]) String() string {
+	elements := make([]string, 0, m.forward.Size())
+
+	m.forward.Each(func(key K, value V) bool {
+		elements = append(elements, fmt.Sprintf("%v:%v", key, value))
+		return true
+	})
+
+	return "BidiMap\nmap[" + strings.Join(elements, " ") + "]"
+}
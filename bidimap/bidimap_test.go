@@ -0,0 +1,175 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-15 15:03:26
+ * @Description  :
+ */
+package bidimap
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+func TestMap(t *testing.T) {
+
+	// id -> name
+	m := NewWith(comparator.IntComparator, comparator.StringComparator)
+
+	m.Put(3, "carol")
+	m.Put(1, "bob")
+	m.Put(2, "alice")
+
+	if actualValue, expectedValue := fmt.Sprint(m.Keys(), m.Values()), "[1 2 3] [bob alice carol]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(m.Inverse().Keys(), m.Inverse().Values()), "[alice bob carol] [2 1 3]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	tests := [][]interface{}{
+		{1, "bob", true},
+		{3, "carol", true},
+		{4, nil, false},
+	}
+
+	for _, test := range tests {
+		if value, found := m.Get(test[0]); value != test[1] || found != test[2] {
+			t.Errorf("Got %v,%v expected %v,%v", value, found, test[1], test[2])
+		}
+		if found := m.Contains(test[0]); found != test[2] {
+			t.Errorf("Got %v expected %v", found, test[2])
+		}
+	}
+
+	if key, found := m.GetKey("alice"); key != 2 || !found || !m.ContainsValue("alice") {
+		t.Errorf("Got %v,%v expected %v,%v", key, found, 2, true)
+	}
+
+	if key, found := m.GetKey("dave"); key != nil || found || m.ContainsValue("dave") {
+		t.Errorf("Got %v,%v expected %v,%v", key, found, nil, false)
+	}
+
+	// overwriting drops the old value and the old key of the value
+	m.Put(1, "dave")
+	m.Put(4, "alice")
+
+	if actualValue, expectedValue := m.String(), "BidiMap\nmap[1:dave 3:carol 4:alice]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if actualValue, expectedValue := m.Inverse().String(), "BidiMap\nmap[alice:4 carol:3 dave:1]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	m.Remove(3)
+	m.Remove(5)
+	m.RemoveValue("alice")
+	m.RemoveValue("eve")
+
+	if actualValue, expectedValue := fmt.Sprint(m.Size(), m.Keys(), m.Inverse().Keys()), "1 [1] [dave]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	m.Clear()
+
+	if !m.Empty() || !m.Inverse().Empty() {
+		t.Errorf("Got %v expected an empty map", m)
+	}
+}
+
+func TestMapPutMistyped(t *testing.T) {
+
+	m := NewWith(comparator.IntComparator, comparator.StringComparator)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+		if !m.Empty() || !m.Inverse().Empty() {
+			t.Errorf("Got %v expected an untouched map", m)
+		}
+	}()
+
+	m.Put(1, 1)
+}
+
+func TestMapIteration(t *testing.T) {
+
+	m := New[string, int]()
+	m.Put("b", 1)
+	m.Put("a", 3)
+	m.Put("c", 2)
+
+	var byKey, byValue []string
+
+	it := m.Iterator()
+	for it.Next() {
+		byKey = append(byKey, fmt.Sprint(it.Key(), it.Value()))
+	}
+
+	inverse := m.Inverse().Iterator()
+	for inverse.Next() {
+		byValue = append(byValue, fmt.Sprint(inverse.Value(), inverse.Key()))
+	}
+
+	m.Inverse().Each(func(value int, key string) bool {
+		byValue = append(byValue, key)
+		return value < 2
+	})
+
+	if actualValue, expectedValue := fmt.Sprint(byKey, byValue), "[a3 b1 c2] [b1 c2 a3 b c]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestMapRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	m := New[int, int]()
+	forward, inverse := map[int]int{}, map[int]int{}
+
+	for i := 0; i < 5000; i++ {
+
+		key, value := r.Intn(100), r.Intn(100)
+
+		switch r.Intn(4) {
+		case 0:
+			m.Remove(key)
+			if value, ok := forward[key]; ok {
+				delete(forward, key)
+				delete(inverse, value)
+			}
+		case 1:
+			m.RemoveValue(value)
+			if key, ok := inverse[value]; ok {
+				delete(forward, key)
+				delete(inverse, value)
+			}
+		default:
+			m.Put(key, value)
+			if old, ok := forward[key]; ok {
+				delete(inverse, old)
+			}
+			if old, ok := inverse[value]; ok {
+				delete(forward, old)
+			}
+			forward[key], inverse[value] = value, key
+		}
+
+		if m.Size() != len(forward) || m.Inverse().Size() != len(inverse) {
+			t.Fatalf("Got %d,%d expected %d,%d after operation %d", m.Size(), m.Inverse().Size(), len(forward), len(inverse), i)
+		}
+	}
+
+	m.Each(func(key, value int) bool {
+		if forward[key] != value || inverse[value] != key {
+			t.Fatalf("Got %v:%v expected %v:%v", key, value, key, forward[key])
+		}
+		return true
+	})
+}
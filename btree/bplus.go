@@ -0,0 +1,551 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-27 10:05:51
+ * @Description  : B+tree, values live in leaves chained by sibling pointers
+ */
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+// B+树与B树的区别：
+// 1. 内部结点只存放用于分割的key，所有的key-value都存放在叶子结点
+// 2. 叶子结点按key的顺序通过Prev/Next指针串联起来，顺序遍历时不需要回到父结点
+// 3. 内部结点的key ki满足：ci子树中所有key < ki <= ci+1子树中所有key
+
+// PlusTree holds elements of the B+tree
+type PlusTree[K, V any] struct {
+	Root       *PlusNode[K, V]  // root node
+	Comparator func(a, b K) int // key comparator
+	size       int              // total number of keys in the tree
+	m          int              // order (maximum number of children, leaves hold at most m-1 entries)
+}
+
+// PlusNode is an internal node holding separator keys and children,
+// or a leaf holding entries and its siblings
+type PlusNode[K, V any] struct {
+	Parent   *PlusNode[K, V]
+	Keys     []K               // separator keys of internal node
+	Children []*PlusNode[K, V] // children of internal node
+	Entries  []Entry[K, V]     // entries of leaf, stored inline
+	Prev     *PlusNode[K, V]   // previous leaf
+	Next     *PlusNode[K, V]   // next leaf
+}
+
+// NewPlus instantiates a B+tree with order(maximum number of children) over an ordered key type,
+// keys are compared with cmp.Compare so no boxing or type assertion is involved.
+func NewPlus[K cmp.Ordered, V any](order int) *PlusTree[K, V] {
+	return NewPlusWithFunc[K, V](order, cmp.Compare[K])
+}
+
+// NewPlusWithFunc instantiates a B+tree with order(maximum number of children) and typed key compare function
+func NewPlusWithFunc[K, V any](order int, compare func(a, b K) int) *PlusTree[K, V] {
+
+	if order < 3 {
+		panic("Invalid order, should be at least 3")
+	}
+
+	return &PlusTree[K, V]{m: order, Comparator: compare}
+}
+
+// NewPlusWith instantiates a B+tree with order(maximum number of children) and costom key comparator,
+// i.e. keys and values are of type interface{}.
+func NewPlusWith(order int, comparator comparator.Comparator) *PlusTree[interface{}, interface{}] {
+	return NewPlusWithFunc[interface{}, interface{}](order, comparator)
+}
+
+// NewPlusWithIntComparator instantiates a B+tree with order(maximum number of children) and IntComparator, i.e. keys are of type int
+func NewPlusWithIntComparator(order int) *PlusTree[interface{}, interface{}] {
+	return NewPlusWith(order, comparator.IntComparator)
+}
+
+// NewPlusWithStringComparator instantiates a B+tree with order(maximum number of children) and StringComparator, i.e. keys are of type string
+func NewPlusWithStringComparator(order int) *PlusTree[interface{}, interface{}] {
+	return NewPlusWith(order, comparator.StringComparator)
+}
+
+// Insert inserts key-value pair into the tree.
+// If key already exists, then its value is updated with the new value
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *PlusTree[K, V]) Insert(key K, value V) {
+
+	entry := Entry[K, V]{Key: key, Value: value}
+
+	if tree.Root == nil {
+		tree.Root = &PlusNode[K, V]{Entries: []Entry[K, V]{entry}}
+		tree.size++
+
+		return
+	}
+
+	leaf := tree.leaf(key)
+
+	index, found := tree.searchEntries(leaf, key)
+	if found {
+		leaf.Entries[index] = entry
+		return
+	}
+
+	leaf.Entries = slices.Insert(leaf.Entries, index, entry)
+	tree.size++
+
+	if len(leaf.Entries) > tree.maxEntries() {
+		tree.splitLeaf(leaf)
+	}
+}
+
+// Get searches the key in the tree and returns its value or zero value if key is not found in tree,
+// Second return parameter is true if key was found, otherwise false.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *PlusTree[K, V]) Get(key K) (value V, found bool) {
+
+	if tree.Root == nil {
+		return value, false
+	}
+
+	leaf := tree.leaf(key)
+
+	if index, found := tree.searchEntries(leaf, key); found {
+		return leaf.Entries[index].Value, true
+	}
+
+	return value, false
+}
+
+// Remove remove the key from the tree.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *PlusTree[K, V]) Remove(key K) {
+
+	if tree.Root == nil {
+		return
+	}
+
+	leaf := tree.leaf(key)
+
+	index, found := tree.searchEntries(leaf, key)
+	if !found {
+		return
+	}
+
+	leaf.Entries = slices.Delete(leaf.Entries, index, index+1)
+	tree.size--
+
+	if leaf == tree.Root {
+		if len(leaf.Entries) == 0 {
+			tree.Root = nil
+		}
+
+		return
+	}
+
+	if len(leaf.Entries) < tree.minEntries() {
+		tree.rebalanceLeaf(leaf)
+	}
+}
+
+// Empty returns true if tree does not contain any keys
+func (tree *PlusTree[K, V]) Empty() bool {
+	return tree.size == 0
+}
+
+// Size returns number of keys in the tree
+func (tree *PlusTree[K, V]) Size() int {
+	return tree.size
+}
+
+// Keys returns all keys in-order
+func (tree *PlusTree[K, V]) Keys() []K {
+
+	keys := make([]K, 0, tree.size)
+
+	for leaf := tree.Left(); leaf != nil; leaf = leaf.Next {
+		for _, entry := range leaf.Entries {
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	return keys
+}
+
+// Values returns all values in-order based on the key.
+func (tree *PlusTree[K, V]) Values() []V {
+
+	values := make([]V, 0, tree.size)
+
+	for leaf := tree.Left(); leaf != nil; leaf = leaf.Next {
+		for _, entry := range leaf.Entries {
+			values = append(values, entry.Value)
+		}
+	}
+
+	return values
+}
+
+// Clear removes all keys from the tree
+func (tree *PlusTree[K, V]) Clear() {
+	tree.Root = nil
+	tree.size = 0
+}
+
+// Height returns the height of the tree
+func (tree *PlusTree[K, V]) Height() int {
+
+	height := 0
+
+	for node := tree.Root; node != nil; height++ {
+		if len(node.Children) == 0 {
+			node = nil
+		} else {
+			node = node.Children[0]
+		}
+	}
+
+	return height
+}
+
+// Left returns the left-most leaf or nil if tree is empty
+func (tree *PlusTree[K, V]) Left() *PlusNode[K, V] {
+
+	node := tree.Root
+
+	for node != nil && len(node.Children) > 0 {
+		node = node.Children[0]
+	}
+
+	return node
+}
+
+// Right returns the right-most leaf or nil if tree is empty
+func (tree *PlusTree[K, V]) Right() *PlusNode[K, V] {
+
+	node := tree.Root
+
+	for node != nil && len(node.Children) > 0 {
+		node = node.Children[len(node.Children)-1]
+	}
+
+	return node
+}
+
+// Range calls fn on every element between lo and hi in ascending order by walking the leaf chain,
+// bounds are included when loInclusive and hiInclusive are true, iteration stops when fn returns false.
+func (tree *PlusTree[K, V]) Range(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+
+	if tree.Root == nil {
+		return
+	}
+
+	leaf := tree.leaf(lo)
+
+	index, found := tree.searchEntries(leaf, lo)
+	if found && !loInclusive {
+		index++
+	}
+
+	for ; leaf != nil; leaf, index = leaf.Next, 0 {
+		for ; index < len(leaf.Entries); index++ {
+
+			entry := leaf.Entries[index]
+
+			compare := tree.Comparator(entry.Key, hi)
+			if compare > 0 || (compare == 0 && !hiInclusive) {
+				return
+			}
+
+			if !fn(entry.Key, entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns a string representation of container (for debugging purposes)
+func (tree *PlusTree[K, V]) String() string {
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString("BPlusTree\n")
+
+	if !tree.Empty() {
+		tree.output(&buffer, tree.Root, 0)
+	}
+
+	return buffer.String()
+}
+
+func (tree *PlusTree[K, V]) output(buffer *bytes.Buffer, node *PlusNode[K, V], level int) {
+
+	if len(node.Children) == 0 {
+		for _, entry := range node.Entries {
+			buffer.WriteString(strings.Repeat("    ", level) + fmt.Sprintf("%v", entry.Key) + "\n")
+		}
+
+		return
+	}
+
+	for i, child := range node.Children {
+
+		tree.output(buffer, child, level+1)
+
+		if i < len(node.Keys) {
+			buffer.WriteString(strings.Repeat("    ", level) + fmt.Sprintf("%v", node.Keys[i]) + "\n")
+		}
+	}
+}
+
+/** inner function related */
+
+// leaf returns the leaf where key is or would be inserted
+func (tree *PlusTree[K, V]) leaf(key K) *PlusNode[K, V] {
+
+	node := tree.Root
+
+	for len(node.Children) > 0 {
+		node = node.Children[tree.childIndex(node, key)]
+	}
+
+	return node
+}
+
+// childIndex returns the index of the child of an internal node covering key,
+// i.e. the number of separators smaller than or equal to key
+func (tree *PlusTree[K, V]) childIndex(node *PlusNode[K, V], key K) int {
+
+	index, found := slices.BinarySearchFunc(node.Keys, key, tree.Comparator)
+	if found {
+		index++
+	}
+
+	return index
+}
+
+func (tree *PlusTree[K, V]) searchEntries(leaf *PlusNode[K, V], key K) (index int, found bool) {
+	return slices.BinarySearchFunc(leaf.Entries, key, func(entry Entry[K, V], key K) int {
+		return tree.Comparator(entry.Key, key)
+	})
+}
+
+// splitLeaf moves the upper half of an overfull leaf into a new right sibling,
+// whose first key is copied up to the parent as separator
+func (tree *PlusTree[K, V]) splitLeaf(leaf *PlusNode[K, V]) {
+
+	middle := len(leaf.Entries) / 2
+
+	right := &PlusNode[K, V]{
+		Parent:  leaf.Parent,
+		Entries: slices.Clone(leaf.Entries[middle:]),
+		Prev:    leaf,
+		Next:    leaf.Next,
+	}
+
+	leaf.Entries = slices.Clip(leaf.Entries[:middle])
+
+	if leaf.Next != nil {
+		leaf.Next.Prev = right
+	}
+
+	leaf.Next = right
+
+	tree.insertIntoParent(leaf, right.Entries[0].Key, right)
+}
+
+// splitInternal moves the upper half of an overfull internal node into a new right sibling,
+// the middle separator moves up to the parent
+func (tree *PlusTree[K, V]) splitInternal(node *PlusNode[K, V]) {
+
+	middle := len(node.Keys) / 2
+	separator := node.Keys[middle]
+
+	right := &PlusNode[K, V]{
+		Parent:   node.Parent,
+		Keys:     slices.Clone(node.Keys[middle+1:]),
+		Children: slices.Clone(node.Children[middle+1:]),
+	}
+
+	node.Keys = slices.Clip(node.Keys[:middle])
+	node.Children = slices.Clip(node.Children[:middle+1])
+
+	for _, child := range right.Children {
+		child.Parent = right
+	}
+
+	tree.insertIntoParent(node, separator, right)
+}
+
+// insertIntoParent inserts separator and right next to its left sibling node in their parent,
+// splitting the parent in turn if it overflows
+func (tree *PlusTree[K, V]) insertIntoParent(node *PlusNode[K, V], separator K, right *PlusNode[K, V]) {
+
+	parent := node.Parent
+
+	if parent == nil {
+		tree.Root = &PlusNode[K, V]{Keys: []K{separator}, Children: []*PlusNode[K, V]{node, right}}
+		node.Parent, right.Parent = tree.Root, tree.Root
+
+		return
+	}
+
+	index := slices.Index(parent.Children, node)
+	parent.Keys = slices.Insert(parent.Keys, index, separator)
+	parent.Children = slices.Insert(parent.Children, index+1, right)
+	right.Parent = parent
+
+	if len(parent.Children) > tree.maxChildren() {
+		tree.splitInternal(parent)
+	}
+}
+
+// rebalanceLeaf refills an underflowed leaf by borrowing from a sibling or merging with it
+func (tree *PlusTree[K, V]) rebalanceLeaf(leaf *PlusNode[K, V]) {
+
+	parent := leaf.Parent
+	index := slices.Index(parent.Children, leaf)
+
+	// borrow from the left sibling
+	if index > 0 {
+		left := parent.Children[index-1]
+
+		if len(left.Entries) > tree.minEntries() {
+			leaf.Entries = slices.Insert(leaf.Entries, 0, left.Entries[len(left.Entries)-1])
+			left.Entries = left.Entries[:len(left.Entries)-1]
+			parent.Keys[index-1] = leaf.Entries[0].Key
+
+			return
+		}
+	}
+
+	// borrow from the right sibling
+	if index < len(parent.Children)-1 {
+		right := parent.Children[index+1]
+
+		if len(right.Entries) > tree.minEntries() {
+			leaf.Entries = append(leaf.Entries, right.Entries[0])
+			right.Entries = slices.Delete(right.Entries, 0, 1)
+			parent.Keys[index] = right.Entries[0].Key
+
+			return
+		}
+	}
+
+	// merge into the left sibling, or merge the right sibling into leaf
+	if index > 0 {
+		leaf, index = parent.Children[index-1], index-1
+	}
+
+	right := parent.Children[index+1]
+
+	leaf.Entries = append(leaf.Entries, right.Entries...)
+	leaf.Next = right.Next
+
+	if right.Next != nil {
+		right.Next.Prev = leaf
+	}
+
+	tree.removeFromParent(parent, index)
+}
+
+// removeFromParent drops the separator at index and the child right of it from an internal node,
+// rebalancing the node in turn if it underflows
+func (tree *PlusTree[K, V]) removeFromParent(node *PlusNode[K, V], index int) {
+
+	node.Keys = slices.Delete(node.Keys, index, index+1)
+	node.Children = slices.Delete(node.Children, index+1, index+2)
+
+	if node == tree.Root {
+		// shrink the tree from the top
+		if len(node.Children) == 1 {
+			tree.Root = node.Children[0]
+			tree.Root.Parent = nil
+		}
+
+		return
+	}
+
+	if len(node.Children) < tree.minChildren() {
+		tree.rebalanceInternal(node)
+	}
+}
+
+// rebalanceInternal refills an underflowed internal node by rotating through the parent or merging with a sibling
+func (tree *PlusTree[K, V]) rebalanceInternal(node *PlusNode[K, V]) {
+
+	parent := node.Parent
+	index := slices.Index(parent.Children, node)
+
+	// borrow from the left sibling
+	if index > 0 {
+		left := parent.Children[index-1]
+
+		if len(left.Children) > tree.minChildren() {
+			child := left.Children[len(left.Children)-1]
+
+			node.Keys = slices.Insert(node.Keys, 0, parent.Keys[index-1])
+			node.Children = slices.Insert(node.Children, 0, child)
+			child.Parent = node
+
+			parent.Keys[index-1] = left.Keys[len(left.Keys)-1]
+			left.Keys = left.Keys[:len(left.Keys)-1]
+			left.Children = left.Children[:len(left.Children)-1]
+
+			return
+		}
+	}
+
+	// borrow from the right sibling
+	if index < len(parent.Children)-1 {
+		right := parent.Children[index+1]
+
+		if len(right.Children) > tree.minChildren() {
+			child := right.Children[0]
+
+			node.Keys = append(node.Keys, parent.Keys[index])
+			node.Children = append(node.Children, child)
+			child.Parent = node
+
+			parent.Keys[index] = right.Keys[0]
+			right.Keys = slices.Delete(right.Keys, 0, 1)
+			right.Children = slices.Delete(right.Children, 0, 1)
+
+			return
+		}
+	}
+
+	// merge into the left sibling, or merge the right sibling into node, pulling the separator down
+	if index > 0 {
+		node, index = parent.Children[index-1], index-1
+	}
+
+	right := parent.Children[index+1]
+
+	node.Keys = append(append(node.Keys, parent.Keys[index]), right.Keys...)
+	node.Children = append(node.Children, right.Children...)
+
+	for _, child := range right.Children {
+		child.Parent = node
+	}
+
+	tree.removeFromParent(parent, index)
+}
+
+func (tree *PlusTree[K, V]) minEntries() int {
+	return tree.m / 2
+}
+
+func (tree *PlusTree[K, V]) maxEntries() int {
+	return tree.m - 1
+}
+
+func (tree *PlusTree[K, V]) minChildren() int {
+	return (tree.m + 1) / 2
+}
+
+func (tree *PlusTree[K, V]) maxChildren() int {
+	return tree.m
+}
@@ -0,0 +1,154 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-27 14:48:26
+ * @Description  :
+ */
+package btree
+
+// PlusIterator holding the iterator's state over a PlusTree,
+// it moves along the leaf chain so every step is O(1).
+type PlusIterator[K, V any] struct {
+	tree     *PlusTree[K, V]
+	leaf     *PlusNode[K, V]
+	index    int
+	position position
+}
+
+// Iterator returns a stateful iterator whose elements are key/value pairs.
+func (tree *PlusTree[K, V]) Iterator() PlusIterator[K, V] {
+	return PlusIterator[K, V]{tree: tree, position: begin}
+}
+
+// IteratorFrom returns a stateful iterator positioned right before the ceiling of key,
+// so Next() fetches the smallest element whose key is larger than or equal to key.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *PlusTree[K, V]) IteratorFrom(key K) PlusIterator[K, V] {
+
+	if tree.Root == nil {
+		return tree.Iterator()
+	}
+
+	leaf := tree.leaf(key)
+	index, _ := tree.searchEntries(leaf, key)
+
+	// stand at the entry right before the ceiling, which may be in the previous leaf
+	if index == 0 {
+		if leaf.Prev == nil {
+			return tree.Iterator()
+		}
+
+		leaf, index = leaf.Prev, len(leaf.Prev.Entries)
+	}
+
+	return PlusIterator[K, V]{tree: tree, leaf: leaf, index: index - 1, position: between}
+}
+
+// Next moves the iterator to the next element and returns true if there was a next element in the container.
+// If Next() returns true, then next element's key and value can be retrieved by Key() and Value()
+// If Next() was called for the first time, then it will point the iterator to the first element if it exists.
+// Modifies the state of the iterator
+func (iterator *PlusIterator[K, V]) Next() bool {
+
+	switch iterator.position {
+	case end:
+		return false
+	case begin:
+		iterator.leaf, iterator.index = iterator.tree.Left(), -1
+	}
+
+	iterator.index++
+
+	for iterator.leaf != nil && iterator.index >= len(iterator.leaf.Entries) {
+		iterator.leaf, iterator.index = iterator.leaf.Next, 0
+	}
+
+	if iterator.leaf == nil {
+		iterator.End()
+		return false
+	}
+
+	iterator.position = between
+
+	return true
+}
+
+// Previous moves the iterator to the previous element and returns true
+// if there was a previous element in the container.
+// if Previous() returns true, then previous elements's key and value
+// can be retrieved by Key() and Value()
+// modifies the state of the iterator.
+func (iterator *PlusIterator[K, V]) Previous() bool {
+
+	switch iterator.position {
+	case begin:
+		return false
+	case end:
+		iterator.leaf = iterator.tree.Right()
+		if iterator.leaf != nil {
+			iterator.index = len(iterator.leaf.Entries)
+		}
+	}
+
+	iterator.index--
+
+	for iterator.leaf != nil && iterator.index < 0 {
+		iterator.leaf = iterator.leaf.Prev
+		if iterator.leaf != nil {
+			iterator.index = len(iterator.leaf.Entries) - 1
+		}
+	}
+
+	if iterator.leaf == nil {
+		iterator.Begin()
+		return false
+	}
+
+	iterator.position = between
+
+	return true
+}
+
+// Key returns the current element's key
+// Does not modify the state of the iterator
+func (iterator *PlusIterator[K, V]) Key() K {
+	return iterator.leaf.Entries[iterator.index].Key
+}
+
+// Value returns the current element's value.
+// Does not modify the state of the iterator
+func (iterator *PlusIterator[K, V]) Value() V {
+	return iterator.leaf.Entries[iterator.index].Value
+}
+
+// Begin resets the iterator to its initial state (one-before-first)
+// Call Next() to fetch the first element if any.
+func (iterator *PlusIterator[K, V]) Begin() {
+	iterator.leaf = nil
+	iterator.index = -1
+	iterator.position = begin
+}
+
+// End moves the iterator past the last element (one-past-the-end)
+// Call Previous() to fetch the last element if any
+func (iterator *PlusIterator[K, V]) End() {
+	iterator.leaf = nil
+	iterator.index = -1
+	iterator.position = end
+}
+
+// First moves the iterator to the first element and
+// returns true if there was a first element in the container.
+// If First() return true, then first element's key and value can be retrieved by Key() and Value().
+// Modifies the state of the iterator
+func (iterator *PlusIterator[K, V]) First() bool {
+	iterator.Begin()
+	return iterator.Next()
+}
+
+// Last moves the iterator to the last element and returns true if there was a last element in the container.
+// If Last() returns true, then last element's key and value can be retrieved by Key() and Value()
+// Modifies the state of the iterator.
+func (iterator *PlusIterator[K, V]) Last() bool {
+	iterator.End()
+	return iterator.Previous()
+}
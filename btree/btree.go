@@ -7,9 +7,11 @@ package btree
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
 	"strings"
 
+	"github.com/Jayj1997/go-common/codec"
 	"github.com/Jayj1997/go-common/comparator"
 )
 
@@ -42,41 +44,68 @@ k1 <= x.key1 <= k2  x.key2 <= ... <= x.keyX.n <= kx.n+1
 每个结点**最多**包含2t-1个关键字。因为，一个内部结点至多可以有2t个孩子。当一个结点恰好有2t-1个关键字时，称该结点是满的(full)
 */
 
-type Tree struct {
-	Root       *Node                 // root node
-	Comparator comparator.Comparator // key comparator
-	size       int                   // total number of keys in the tree
-	m          int                   // order (maximum number of children)
+type Tree[K, V any] struct {
+	Root       *Node[K, V]                  // root node
+	Comparator func(a, b K) int             // key comparator
+	KeyCodec   codec.Codec[K]               // key codec of binary serialization, gob if nil
+	ValueCodec codec.Codec[V]               // value codec of binary serialization, gob if nil
+	KeyDecoder func(data []byte) (K, error) // decodes JSON keys, see FromJSON
+	size       int                          // total number of keys in the tree
+	m          int                          // order (maximum number of children)
 }
 
-type Node struct {
-	Parent   *Node
-	Entries  []*Entry // contained keys in node
-	Children []*Node  // children nodes
+type Node[K, V any] struct {
+	Parent   *Node[K, V]
+	Entries  []Entry[K, V] // contained keys in node, stored inline
+	Children []*Node[K, V] // children nodes
 }
 
-type Entry struct {
-	Key   interface{}
-	Value interface{}
+type Entry[K, V any] struct {
+	Key   K
+	Value V
 }
 
-// NewWith instantiates a B-tree with order(maximum number of children) and costom key comparator
-func NewWith(order int, comparator comparator.Comparator) *Tree {
+// New instantiates a B-tree with order(maximum number of children) over an ordered key type,
+// keys are compared with cmp.Compare so no boxing or type assertion is involved.
+func New[K cmp.Ordered, V any](order int) *Tree[K, V] {
+	return NewWithFunc[K, V](order, cmp.Compare[K])
+}
+
+// NewWithFunc instantiates a B-tree with order(maximum number of children) and typed key compare function
+func NewWithFunc[K, V any](order int, compare func(a, b K) int) *Tree[K, V] {
 	if order < 3 {
 		panic("Invalid order, should be at least 3")
 	}
 
-	return &Tree{m: order, Comparator: comparator}
+	return &Tree[K, V]{m: order, Comparator: compare}
+}
+
+// NewWith instantiates a B-tree with order(maximum number of children) and costom key comparator,
+// i.e. keys and values are of type interface{}.
+// It's the adapter for callers built on comparator.Comparator.
+func NewWith(order int, comparator comparator.Comparator) *Tree[interface{}, interface{}] {
+	return NewWithFunc[interface{}, interface{}](order, comparator)
 }
 
 // NewWithIntComparator instantiates a B-tree with the order (maximum number of children) and the IntComparator, i.e. keys are of type int.
-func NewWithIntComparator(order int) *Tree {
-	return NewWith(order, comparator.IntComparator)
+func NewWithIntComparator(order int) *Tree[interface{}, interface{}] {
+	tree := NewWith(order, comparator.IntComparator)
+	tree.KeyDecoder = KeyDecoderOf[int]()
+	return tree
 }
 
 // NewWithStringComparator instantiates a B-tree with the order (maximum number of children) and the StringComparator, i.e. keys are of type string.
-func NewWithStringComparator(order int) *Tree {
-	return NewWith(order, comparator.StringComparator)
+func NewWithStringComparator(order int) *Tree[interface{}, interface{}] {
+	tree := NewWith(order, comparator.StringComparator)
+	tree.KeyDecoder = KeyDecoderOf[string]()
+	return tree
+}
+
+// NewWithAutoComparator instantiates a B-tree with the order (maximum number of children) and comparator.Auto, i.e. keys are of mixed primitive types.
+func NewWithAutoComparator(order int) *Tree[interface{}, interface{}] {
+	tree := NewWith(order, comparator.Auto)
+	tree.KeyDecoder = AutoKeyDecoder()
+	return tree
 }
 
 /** function related */
@@ -84,12 +113,12 @@ func NewWithStringComparator(order int) *Tree {
 // Insert inserts key-value pair node into the tree.
 // If key already exists, then its value is updated with the new value
 // Key should adhere to the comparator's type assertion, otherwise method panics
-func (tree *Tree) Insert(key, value interface{}) {
+func (tree *Tree[K, V]) Insert(key K, value V) {
 
-	entry := &Entry{Key: key, Value: value}
+	entry := Entry[K, V]{Key: key, Value: value}
 
 	if tree.Root == nil {
-		tree.Root = &Node{Entries: []*Entry{entry}, Children: []*Node{}}
+		tree.Root = &Node[K, V]{Entries: []Entry[K, V]{entry}, Children: []*Node[K, V]{}}
 
 		tree.size++
 
@@ -104,18 +133,18 @@ func (tree *Tree) Insert(key, value interface{}) {
 // Get searches the node in the tree by key and returns its value or nil if key is not found in tree,
 // Second return parameter is true if key was found, otherwise false.
 // Key should adhere to the comparator's type assertion, otherwise method panics
-func (tree *Tree) Get(key interface{}) (value interface{}, found bool) {
+func (tree *Tree[K, V]) Get(key K) (value V, found bool) {
 	node, index, found := tree.searchRecursively(tree.Root, key)
 	if found {
 		return node.Entries[index].Value, true
 	}
 
-	return nil, false
+	return value, false
 }
 
 // Remove remove the node from the tree by key.
 // Key should adhere to the comparator's type assertion, otherwise method panics
-func (tree *Tree) Remove(key interface{}) {
+func (tree *Tree[K, V]) Remove(key K) {
 	node, index, found := tree.searchRecursively(tree.Root, key)
 
 	if found {
@@ -124,19 +153,19 @@ func (tree *Tree) Remove(key interface{}) {
 	}
 }
 
-func (tree *Tree) Empty() bool {
+func (tree *Tree[K, V]) Empty() bool {
 	return tree.size == 0
 }
 
 // Size returns number of nodes in the tree
-func (tree *Tree) Size() int {
+func (tree *Tree[K, V]) Size() int {
 	return tree.size
 }
 
 // Keys returns all keys in-order
-func (tree *Tree) Keys() []interface{} {
+func (tree *Tree[K, V]) Keys() []K {
 
-	keys := make([]interface{}, tree.size)
+	keys := make([]K, tree.size)
 	it := tree.Iterator()
 
 	for i := 0; it.Next(); i++ {
@@ -146,9 +175,9 @@ func (tree *Tree) Keys() []interface{} {
 	return keys
 }
 
-func (tree *Tree) Values() []interface{} {
+func (tree *Tree[K, V]) Values() []V {
 
-	values := make([]interface{}, tree.size)
+	values := make([]V, tree.size)
 	it := tree.Iterator()
 
 	for i := 0; it.Next(); i++ {
@@ -160,76 +189,110 @@ func (tree *Tree) Values() []interface{} {
 }
 
 // clear removes all nodes from the tree
-func (tree *Tree) Clear() {
+func (tree *Tree[K, V]) Clear() {
 
 	tree.Root = nil
 	tree.size = 0
 }
 
 // Height returns the height of the tree
-func (tree *Tree) Height() int {
+func (tree *Tree[K, V]) Height() int {
 
 	return tree.Root.height()
 }
 
 // Left returns the left-most(min) node or nil if tree was empty
-func (tree *Tree) Left() *Node {
+func (tree *Tree[K, V]) Left() *Node[K, V] {
 
 	return tree.left(tree.Root)
 }
 
 // LeftKey returns the left-most(min) key or nil if tree was empty
-func (tree *Tree) LeftKey() interface{} {
+func (tree *Tree[K, V]) LeftKey() (key K) {
 
 	if left := tree.Left(); left != nil {
 
 		return left.Entries[0].Key
 	}
 
-	return nil
+	return key
 }
 
 // LeftValue returns the left-most(min) value or nil if tree was empty
-func (tree *Tree) LeftValue() interface{} {
+func (tree *Tree[K, V]) LeftValue() (value V) {
 
 	if left := tree.Left(); left != nil {
 
 		return left.Entries[0].Value
 	}
 
-	return nil
+	return value
 }
 
 // Right returns the right-most(max) node or nil if tree was empty
-func (tree *Tree) Right() *Node {
+func (tree *Tree[K, V]) Right() *Node[K, V] {
 
 	return tree.right(tree.Root)
 }
 
 // RightKey returns the right-most(max) key or nil if tree is empty
-func (tree *Tree) RightKey() interface{} {
+func (tree *Tree[K, V]) RightKey() (key K) {
 
 	if right := tree.Right(); right != nil {
 
 		return right.Entries[len(right.Entries)-1].Key
 	}
 
-	return nil
+	return key
 }
 
 // RightValue returns the right-most(max) value or nil if tree was empty
-func (tree *Tree) RightValue() interface{} {
+func (tree *Tree[K, V]) RightValue() (value V) {
 
 	if right := tree.Right(); right != nil {
 
 		return right.Entries[len(right.Entries)-1].Value
 	}
 
-	return nil
+	return value
+}
+
+// Floor returns the largest key smaller than or equal to the given key and its value.
+// Third return parameter is false if no such key was found,
+// either because the tree is empty, or all keys in the tree are larger than the given key.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) Floor(key K) (K, V, bool) {
+
+	return entryAt(tree.floor(key, true))
+}
+
+// Lower returns the largest key strictly smaller than the given key and its value.
+// Third return parameter is false if no such key was found.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) Lower(key K) (K, V, bool) {
+
+	return entryAt(tree.floor(key, false))
+}
+
+// Ceiling returns the smallest key larger than or equal to the given key and its value.
+// Third return parameter is false if no such key was found,
+// either because the tree is empty, or all keys in the tree are smaller than the given key.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) Ceiling(key K) (K, V, bool) {
+
+	return entryAt(tree.ceiling(key, true))
+}
+
+// Higher returns the smallest key strictly larger than the given key and its value.
+// Third return parameter is false if no such key was found.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) Higher(key K) (K, V, bool) {
+
+	return entryAt(tree.ceiling(key, false))
 }
 
 // String returns a string representation of container (for debugging purposes)
-func (tree *Tree) String() string {
+func (tree *Tree[K, V]) String() string {
 
 	var buffer bytes.Buffer
 
@@ -245,12 +308,12 @@ func (tree *Tree) String() string {
 	return buffer.String()
 }
 
-func (entry *Entry) String() string {
+func (entry *Entry[K, V]) String() string {
 
 	return fmt.Sprintf("%v", entry.Key)
 }
 
-func (tree *Tree) output(buffer *bytes.Buffer, node *Node, level int, isTail bool) {
+func (tree *Tree[K, V]) output(buffer *bytes.Buffer, node *Node[K, V], level int, isTail bool) {
 
 	for e := 0; e < len(node.Entries)+1; e++ {
 
@@ -272,7 +335,7 @@ func (tree *Tree) output(buffer *bytes.Buffer, node *Node, level int, isTail boo
 
 /** inner function related */
 
-func (node *Node) height() int {
+func (node *Node[K, V]) height() int {
 
 	height := 0
 
@@ -288,7 +351,7 @@ func (node *Node) height() int {
 	return height
 }
 
-func (tree *Tree) insert(node *Node, entry *Entry) (inserted bool) {
+func (tree *Tree[K, V]) insert(node *Node[K, V], entry Entry[K, V]) (inserted bool) {
 	if tree.isLeaf(node) {
 		return tree.insertIntoLeaf(node, entry)
 	}
@@ -296,7 +359,7 @@ func (tree *Tree) insert(node *Node, entry *Entry) (inserted bool) {
 	return tree.insertIntoInternal(node, entry)
 }
 
-func (tree *Tree) insertIntoLeaf(node *Node, entry *Entry) (inserted bool) {
+func (tree *Tree[K, V]) insertIntoLeaf(node *Node[K, V], entry Entry[K, V]) (inserted bool) {
 	insertPosition, found := tree.search(node, entry.Key)
 
 	if found {
@@ -305,7 +368,7 @@ func (tree *Tree) insertIntoLeaf(node *Node, entry *Entry) (inserted bool) {
 	}
 
 	// Insert entry's key in the middle of the node
-	node.Entries = append(node.Entries, nil)
+	node.Entries = append(node.Entries, Entry[K, V]{})
 
 	copy(node.Entries[insertPosition+1:], node.Entries[insertPosition:])
 
@@ -316,7 +379,7 @@ func (tree *Tree) insertIntoLeaf(node *Node, entry *Entry) (inserted bool) {
 	return true
 }
 
-func (tree *Tree) insertIntoInternal(node *Node, entry *Entry) (inserted bool) {
+func (tree *Tree[K, V]) insertIntoInternal(node *Node[K, V], entry Entry[K, V]) (inserted bool) {
 
 	insertPosition, found := tree.search(node, entry.Key)
 	if found {
@@ -327,11 +390,108 @@ func (tree *Tree) insertIntoInternal(node *Node, entry *Entry) (inserted bool) {
 	return tree.insert(node.Children[insertPosition], entry)
 }
 
-func (tree *Tree) isLeaf(node *Node) bool {
+// floor returns the node and entry index of the largest key smaller than key,
+// an equal key is accepted when inclusive is true
+func (tree *Tree[K, V]) floor(key K, inclusive bool) (floor *Node[K, V], index int, found bool) {
+
+	node := tree.Root
+
+	for node != nil {
+		position, equal := tree.search(node, key)
+
+		if equal {
+			if inclusive {
+				return node, position, true
+			}
+
+			// largest key in the left sub-tree of the equal entry
+			if !tree.isLeaf(node) {
+				largest := tree.right(node.Children[position])
+				return largest, len(largest.Entries) - 1, true
+			}
+
+			if position > 0 {
+				return node, position - 1, true
+			}
+
+			break
+		}
+
+		// entry left of the insert position is smaller than key,
+		// and larger than any candidate found higher up the tree
+		if position > 0 {
+			floor, index, found = node, position-1, true
+		}
+
+		if tree.isLeaf(node) {
+			break
+		}
+
+		node = node.Children[position]
+	}
+
+	return floor, index, found
+}
+
+// ceiling returns the node and entry index of the smallest key larger than key,
+// an equal key is accepted when inclusive is true
+func (tree *Tree[K, V]) ceiling(key K, inclusive bool) (ceiling *Node[K, V], index int, found bool) {
+
+	node := tree.Root
+
+	for node != nil {
+		position, equal := tree.search(node, key)
+
+		if equal {
+			if inclusive {
+				return node, position, true
+			}
+
+			// smallest key in the right sub-tree of the equal entry
+			if !tree.isLeaf(node) {
+				return tree.left(node.Children[position+1]), 0, true
+			}
+
+			if position+1 < len(node.Entries) {
+				return node, position + 1, true
+			}
+
+			break
+		}
+
+		// entry at the insert position is larger than key,
+		// and smaller than any candidate found higher up the tree
+		if position < len(node.Entries) {
+			ceiling, index, found = node, position, true
+		}
+
+		if tree.isLeaf(node) {
+			break
+		}
+
+		node = node.Children[position]
+	}
+
+	return ceiling, index, found
+}
+
+// entryAt unpacks the entry at index of node as returned by floor and ceiling
+func entryAt[K, V any](node *Node[K, V], index int, found bool) (key K, value V, ok bool) {
+
+	if !found {
+		return key, value, false
+	}
+
+	entry := node.Entries[index]
+
+	return entry.Key, entry.Value, true
+}
+
+func (tree *Tree[K, V]) isLeaf(node *Node[K, V]) bool {
 	return len(node.Children) == 0
 }
 
-func (tree *Tree) search(node *Node, key interface{}) (index int, found bool) {
+func (tree *Tree[K, V]) search(node *Node[K, V], key K) (index int, found bool) {
 
 	low, high := 0, len(node.Entries)-1
 
@@ -357,7 +517,7 @@ func (tree *Tree) search(node *Node, key interface{}) (index int, found bool) {
 }
 
 // searchRecursively searches recursively down the tree starting at the startNode
-func (tree *Tree) searchRecursively(startNode *Node, key interface{}) (node *Node, index int, found bool) {
+func (tree *Tree[K, V]) searchRecursively(startNode *Node[K, V], key K) (node *Node[K, V], index int, found bool) {
 	if tree.Empty() {
 		return nil, -1, false
 	}
@@ -379,7 +539,7 @@ func (tree *Tree) searchRecursively(startNode *Node, key interface{}) (node *Nod
 	}
 }
 
-func (tree *Tree) split(node *Node) {
+func (tree *Tree[K, V]) split(node *Node[K, V]) {
 
 	if !tree.shouldSplit(node) {
 		return
@@ -393,18 +553,18 @@ func (tree *Tree) split(node *Node) {
 	tree.splitNonRoot(node)
 }
 
-func (tree *Tree) splitNonRoot(node *Node) {
+func (tree *Tree[K, V]) splitNonRoot(node *Node[K, V]) {
 
 	middle := tree.middle()
 	parent := node.Parent
 
-	left := &Node{Entries: append([]*Entry(nil), node.Entries[:middle]...), Parent: parent}
-	right := &Node{Entries: append([]*Entry(nil), node.Entries[middle+1:]...), Parent: parent}
+	left := &Node[K, V]{Entries: append([]Entry[K, V](nil), node.Entries[:middle]...), Parent: parent}
+	right := &Node[K, V]{Entries: append([]Entry[K, V](nil), node.Entries[middle+1:]...), Parent: parent}
 
 	// Move children from the node to be split into left and right nodes
 	if !tree.isLeaf(node) {
-		left.Children = append([]*Node(nil), node.Children[:middle+1]...)
-		right.Children = append([]*Node(nil), node.Children[middle+1:]...)
+		left.Children = append([]*Node[K, V](nil), node.Children[:middle+1]...)
+		right.Children = append([]*Node[K, V](nil), node.Children[middle+1:]...)
 
 		setParent(left.Children, left)
 		setParent(right.Children, right)
@@ -413,7 +573,7 @@ func (tree *Tree) splitNonRoot(node *Node) {
 	insertPosition, _ := tree.search(parent, node.Entries[middle].Key)
 
 	// Insert middle key into parent
-	parent.Entries = append(parent.Entries, nil)
+	parent.Entries = append(parent.Entries, Entry[K, V]{})
 	copy(parent.Entries[insertPosition+1:], parent.Entries[insertPosition:])
 	parent.Entries[insertPosition] = node.Entries[middle]
 
@@ -428,49 +588,49 @@ func (tree *Tree) splitNonRoot(node *Node) {
 	tree.split(parent)
 }
 
-func (tree *Tree) shouldSplit(node *Node) bool {
+func (tree *Tree[K, V]) shouldSplit(node *Node[K, V]) bool {
 	return len(node.Entries) > tree.maxEntries()
 }
 
-func (tree *Tree) minEntries() int {
+func (tree *Tree[K, V]) minEntries() int {
 	return tree.minChildren() - 1
 }
 
-func (tree *Tree) maxEntries() int {
+func (tree *Tree[K, V]) maxEntries() int {
 	return tree.maxChildren() - 1
 }
 
-func (tree *Tree) minChildren() int {
+func (tree *Tree[K, V]) minChildren() int {
 	return (tree.m + 1) / 2
 }
 
-func (tree *Tree) maxChildren() int {
+func (tree *Tree[K, V]) maxChildren() int {
 	return tree.m
 }
 
-func (tree *Tree) middle() int {
+func (tree *Tree[K, V]) middle() int {
 	return (tree.m - 1) / 2
 }
 
-func (tree *Tree) splitRoot() {
+func (tree *Tree[K, V]) splitRoot() {
 
 	middle := tree.middle()
 
-	left := &Node{Entries: append([]*Entry(nil), tree.Root.Entries[:middle]...)}
-	right := &Node{Entries: append([]*Entry(nil), tree.Root.Entries[middle+1:]...)}
+	left := &Node[K, V]{Entries: append([]Entry[K, V](nil), tree.Root.Entries[:middle]...)}
+	right := &Node[K, V]{Entries: append([]Entry[K, V](nil), tree.Root.Entries[middle+1:]...)}
 
 	// Move children from the node to be split into left and right nodes
 	if !tree.isLeaf(tree.Root) {
-		left.Children = append([]*Node(nil), tree.Root.Children[:middle+1]...)
-		right.Children = append([]*Node(nil), tree.Root.Children[middle+1:]...)
+		left.Children = append([]*Node[K, V](nil), tree.Root.Children[:middle+1]...)
+		right.Children = append([]*Node[K, V](nil), tree.Root.Children[middle+1:]...)
 		setParent(left.Children, left)
 		setParent(right.Children, right)
 	}
 
 	// Root is a node with one entry and two children (left & right)
-	newRoot := &Node{
-		Entries:  []*Entry{tree.Root.Entries[middle]},
-		Children: []*Node{left, right},
+	newRoot := &Node[K, V]{
+		Entries:  []Entry[K, V]{tree.Root.Entries[middle]},
+		Children: []*Node[K, V]{left, right},
 	}
 
 	left.Parent = newRoot
@@ -478,13 +638,41 @@ func (tree *Tree) splitRoot() {
 	tree.Root = newRoot
 }
 
-func setParent(nodes []*Node, parent *Node) {
+// clone returns a deep copy of the tree structure, keys and values themselves are copied by assignment
+func (tree *Tree[K, V]) clone() *Tree[K, V] {
+
+	clone := *tree
+	clone.Root = cloneNode(tree.Root, nil)
+
+	return &clone
+}
+
+func cloneNode[K, V any](node *Node[K, V], parent *Node[K, V]) *Node[K, V] {
+
+	if node == nil {
+		return nil
+	}
+
+	clone := &Node[K, V]{
+		Parent:   parent,
+		Entries:  append([]Entry[K, V](nil), node.Entries...),
+		Children: make([]*Node[K, V], len(node.Children)),
+	}
+
+	for i, child := range node.Children {
+		clone.Children[i] = cloneNode(child, clone)
+	}
+
+	return clone
+}
+
+func setParent[K, V any](nodes []*Node[K, V], parent *Node[K, V]) {
 	for _, node := range nodes {
 		node.Parent = parent
 	}
 }
 
-func (tree *Tree) left(node *Node) *Node {
+func (tree *Tree[K, V]) left(node *Node[K, V]) *Node[K, V] {
 
 	if tree.Empty() {
 		return nil
@@ -501,7 +689,7 @@ func (tree *Tree) left(node *Node) *Node {
 	}
 }
 
-func (tree *Tree) right(node *Node) *Node {
+func (tree *Tree[K, V]) right(node *Node[K, V]) *Node[K, V] {
 
 	if tree.Empty() {
 		return nil
@@ -520,7 +708,7 @@ func (tree *Tree) right(node *Node) *Node {
 
 // leftSibling returns the node's left sibling and child index (in parent) if it exists, otherwise (nil, -1)
 // key is any of keys in node (could even be deleted)
-func (tree *Tree) leftSibling(node *Node, key interface{}) (*Node, int) {
+func (tree *Tree[K, V]) leftSibling(node *Node[K, V], key K) (*Node[K, V], int) {
 
 	if node.Parent != nil {
 		index, _ := tree.search(node.Parent, key)
@@ -536,7 +724,7 @@ func (tree *Tree) leftSibling(node *Node, key interface{}) (*Node, int) {
 
 // rightSibling returns the node's right sibling and child index (in parent) if it exists, otherwise (nil, -1)
 // key is any of keys in node (could even be deleted)
-func (tree *Tree) rightSibling(node *Node, key interface{}) (*Node, int) {
+func (tree *Tree[K, V]) rightSibling(node *Node[K, V], key K) (*Node[K, V], int) {
 
 	if node.Parent != nil {
 		index, _ := tree.search(node.Parent, key)
@@ -552,7 +740,7 @@ func (tree *Tree) rightSibling(node *Node, key interface{}) (*Node, int) {
 
 // delete deletes an entry in node at entries' index
 // ref: http://en.wikipedia.org/wiki/B-tree#Deletion
-func (tree *Tree) delete(node *Node, index int) {
+func (tree *Tree[K, V]) delete(node *Node[K, V], index int) {
 
 	// deleting from a leaf node
 	if tree.isLeaf(node) {
@@ -578,7 +766,7 @@ func (tree *Tree) delete(node *Node, index int) {
 
 // rebalance rebalances the tree after deletion if necessary and returns true, otherwise false.
 // Note the we first delete the entry and then call rebalance, thus the passed deleted key as reference
-func (tree *Tree) rebalance(node *Node, deletedKey interface{}) {
+func (tree *Tree[K, V]) rebalance(node *Node[K, V], deletedKey K) {
 
 	// check if rebalancing is needed
 	if node == nil || len(node.Entries) >= tree.minEntries() {
@@ -591,7 +779,7 @@ func (tree *Tree) rebalance(node *Node, deletedKey interface{}) {
 
 		// rotate right
 		// prepend parent's separator entry to node's entries
-		node.Entries = append([]*Entry{node.Parent.Entries[leftSiblingIndex]}, node.Entries...)
+		node.Entries = append([]Entry[K, V]{node.Parent.Entries[leftSiblingIndex]}, node.Entries...)
 		node.Parent.Entries[leftSiblingIndex] = leftSibling.Entries[len(leftSibling.Entries)-1]
 		tree.deleteEntry(leftSibling, len(leftSibling.Entries)-1)
 
@@ -599,7 +787,7 @@ func (tree *Tree) rebalance(node *Node, deletedKey interface{}) {
 
 			leftSiblingRightMostChild := leftSibling.Children[len(leftSibling.Children)-1]
 			leftSiblingRightMostChild.Parent = node
-			node.Children = append([]*Node{leftSiblingRightMostChild}, node.Children...)
+			node.Children = append([]*Node[K, V]{leftSiblingRightMostChild}, node.Children...)
 			tree.deleteChild(leftSibling, len(leftSibling.Children)-1)
 		}
 
@@ -640,7 +828,7 @@ func (tree *Tree) rebalance(node *Node, deletedKey interface{}) {
 	} else if leftSibling != nil {
 
 		// merge with left sibling
-		entries := append([]*Entry(nil), leftSibling.Entries...)
+		entries := append([]Entry[K, V](nil), leftSibling.Entries...)
 		entries = append(entries, node.Parent.Entries[leftSiblingIndex])
 		node.Entries = append(entries, node.Entries...)
 		deletedKey = node.Parent.Entries[leftSiblingIndex].Key
@@ -663,27 +851,27 @@ func (tree *Tree) rebalance(node *Node, deletedKey interface{}) {
 
 }
 
-func (tree *Tree) prependChildren(fromNode *Node, toNode *Node) {
+func (tree *Tree[K, V]) prependChildren(fromNode *Node[K, V], toNode *Node[K, V]) {
 
-	children := append([]*Node(nil), fromNode.Children...)
+	children := append([]*Node[K, V](nil), fromNode.Children...)
 	toNode.Children = append(children, toNode.Children...)
 	setParent(fromNode.Children, toNode)
 }
 
-func (tree *Tree) appendChildren(fromNode *Node, toNode *Node) {
+func (tree *Tree[K, V]) appendChildren(fromNode *Node[K, V], toNode *Node[K, V]) {
 
 	toNode.Children = append(toNode.Children, fromNode.Children...)
 	setParent(fromNode.Children, toNode)
 }
 
-func (tree *Tree) deleteEntry(node *Node, index int) {
+func (tree *Tree[K, V]) deleteEntry(node *Node[K, V], index int) {
 
 	copy(node.Entries[index:], node.Entries[index+1:])
-	node.Entries[len(node.Entries)-1] = nil
+	node.Entries[len(node.Entries)-1] = Entry[K, V]{}
 	node.Entries = node.Entries[:len(node.Entries)-1]
 }
 
-func (tree *Tree) deleteChild(node *Node, index int) {
+func (tree *Tree[K, V]) deleteChild(node *Node[K, V], index int) {
 
 	if index >= len(node.Children) {
 		return
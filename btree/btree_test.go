@@ -6,8 +6,24 @@
 package btree
 
 import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/Jayj1997/go-common/codec"
+	"github.com/Jayj1997/go-common/comparator"
 )
 
 func TestBTreeGet1(t *testing.T) {
@@ -654,6 +670,142 @@ func TestBTreeLeftAndRight(t *testing.T) {
 	}
 }
 
+func TestBTreeFloorAndCeiling(t *testing.T) {
+	tree := NewWithIntComparator(3)
+
+	if key, value, found := tree.Floor(0); key != nil || value != nil || found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, nil, nil, false)
+	}
+	if key, value, found := tree.Ceiling(0); key != nil || value != nil || found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, nil, nil, false)
+	}
+
+	for _, key := range []int{10, 20, 30, 40, 50, 60, 70} {
+		tree.Insert(key, fmt.Sprint(key))
+	}
+
+	tests := [][]interface{}{
+		// key, floor, lower, ceiling, higher
+		{5, nil, nil, 10, 10},
+		{10, 10, nil, 10, 20},
+		{15, 10, 10, 20, 20},
+		{40, 40, 30, 40, 50},
+		{45, 40, 40, 50, 50},
+		{70, 70, 60, 70, nil},
+		{75, 70, 70, nil, nil},
+	}
+
+	for _, test := range tests {
+		floor, _, _ := tree.Floor(test[0])
+		lower, _, _ := tree.Lower(test[0])
+		ceiling, _, _ := tree.Ceiling(test[0])
+		higher, _, _ := tree.Higher(test[0])
+		if floor != test[1] || lower != test[2] || ceiling != test[3] || higher != test[4] {
+			t.Errorf("Got %v,%v,%v,%v expected %v,%v,%v,%v for key %v", floor, lower, ceiling, higher, test[1], test[2], test[3], test[4], test[0])
+		}
+	}
+
+	if key, value, found := tree.Floor(45); key != 40 || value != "40" || !found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, 40, "40", true)
+	}
+}
+
+func TestBTreeFloorAndCeilingRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+
+	for _, order := range []int{3, 4, 5, 8} {
+		tree := New[int, int](order)
+		present := map[int]bool{}
+
+		for i := 0; i < 300; i++ {
+			key := random.Intn(200) * 2
+			if random.Intn(3) == 0 {
+				tree.Remove(key)
+				delete(present, key)
+			} else {
+				tree.Insert(key, key)
+				present[key] = true
+			}
+		}
+
+		for key := -1; key <= 401; key++ {
+			expectedFloor, expectedLower, expectedCeiling, expectedHigher := -1, -1, -1, -1
+			for k := key; k >= 0 && expectedFloor < 0; k-- {
+				if present[k] {
+					expectedFloor = k
+				}
+			}
+			for k := key - 1; k >= 0 && expectedLower < 0; k-- {
+				if present[k] {
+					expectedLower = k
+				}
+			}
+			for k := key; k <= 400 && expectedCeiling < 0; k++ {
+				if present[k] {
+					expectedCeiling = k
+				}
+			}
+			for k := key + 1; k <= 400 && expectedHigher < 0; k++ {
+				if present[k] {
+					expectedHigher = k
+				}
+			}
+
+			actual := func(k int, _ int, found bool) int {
+				if !found {
+					return -1
+				}
+				return k
+			}
+			if floor := actual(tree.Floor(key)); floor != expectedFloor {
+				t.Errorf("Got %v expected %v for floor of %v in order %v", floor, expectedFloor, key, order)
+			}
+			if lower := actual(tree.Lower(key)); lower != expectedLower {
+				t.Errorf("Got %v expected %v for lower of %v in order %v", lower, expectedLower, key, order)
+			}
+			if ceiling := actual(tree.Ceiling(key)); ceiling != expectedCeiling {
+				t.Errorf("Got %v expected %v for ceiling of %v in order %v", ceiling, expectedCeiling, key, order)
+			}
+			if higher := actual(tree.Higher(key)); higher != expectedHigher {
+				t.Errorf("Got %v expected %v for higher of %v in order %v", higher, expectedHigher, key, order)
+			}
+
+			it := tree.IteratorFrom(key)
+			next := -1
+			if it.Next() {
+				next = it.Key()
+			}
+			if next != expectedCeiling {
+				t.Errorf("Got %v expected %v for iterator from %v in order %v", next, expectedCeiling, key, order)
+			}
+		}
+	}
+}
+
+func TestBTreeIteratorFrom(t *testing.T) {
+	tree := New[int, string](3)
+	for i := 1; i <= 20; i++ {
+		tree.Insert(i*10, fmt.Sprint(i))
+	}
+
+	// time-window style scan over [45, 95)
+	keys := []int{}
+	for it := tree.IteratorFrom(45); it.Next() && it.Key() < 95; {
+		keys = append(keys, it.Key())
+	}
+	if actualValue, expectedValue := fmt.Sprint(keys), "[50 60 70 80 90]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	it := tree.IteratorFrom(1000)
+	if it.Next() {
+		t.Errorf("Got %v expected end", it.Key())
+	}
+	if !it.Previous() || it.Key() != 200 {
+		t.Errorf("Got %v expected %v", it.Key(), 200)
+	}
+}
+
 func TestBTreeIteratorValuesAndKeys(t *testing.T) {
 	tree := NewWithIntComparator(4)
 	tree.Insert(4, "d")
@@ -1017,7 +1169,7 @@ func TestBTreeIteratorLast(t *testing.T) {
 func TestBTree_search(t *testing.T) {
 	{
 		tree := NewWithIntComparator(3)
-		tree.Root = &Node{Entries: []*Entry{}, Children: make([]*Node, 0)}
+		tree.Root = &Node[interface{}, interface{}]{Entries: []Entry[interface{}, interface{}]{}, Children: make([]*Node[interface{}, interface{}], 0)}
 		tests := [][]interface{}{
 			{0, 0, false},
 		}
@@ -1033,7 +1185,7 @@ func TestBTree_search(t *testing.T) {
 	}
 	{
 		tree := NewWithIntComparator(3)
-		tree.Root = &Node{Entries: []*Entry{{2, 0}, {4, 1}, {6, 2}}, Children: []*Node{}}
+		tree.Root = &Node[interface{}, interface{}]{Entries: []Entry[interface{}, interface{}]{{2, 0}, {4, 1}, {6, 2}}, Children: []*Node[interface{}, interface{}]{}}
 		tests := [][]interface{}{
 			{0, 0, false},
 			{1, 0, false},
@@ -1056,13 +1208,13 @@ func TestBTree_search(t *testing.T) {
 	}
 }
 
-func assertValidTree(t *testing.T, tree *Tree, expectedSize int) {
+func assertValidTree(t *testing.T, tree *Tree[interface{}, interface{}], expectedSize int) {
 	if actualValue, expectedValue := tree.size, expectedSize; actualValue != expectedValue {
 		t.Errorf("Got %v expected %v for tree size", actualValue, expectedValue)
 	}
 }
 
-func assertValidTreeNode(t *testing.T, node *Node, expectedEntries int, expectedChildren int, keys []int, hasParent bool) {
+func assertValidTreeNode(t *testing.T, node *Node[interface{}, interface{}], expectedEntries int, expectedChildren int, keys []int, hasParent bool) {
 	if actualValue, expectedValue := node.Parent != nil, hasParent; actualValue != expectedValue {
 		t.Errorf("Got %v expected %v for hasParent", actualValue, expectedValue)
 		t.FailNow()
@@ -1083,6 +1235,334 @@ func assertValidTreeNode(t *testing.T, node *Node, expectedEntries int, expected
 	}
 }
 
+func TestBTreeSync(t *testing.T) {
+	tree := NewSync[int, int](3)
+
+	if actual, loaded := tree.GetOrInsert(1, 10); actual != 10 || loaded {
+		t.Errorf("Got %v,%v expected %v,%v", actual, loaded, 10, false)
+	}
+	if actual, loaded := tree.GetOrInsert(1, 20); actual != 10 || !loaded {
+		t.Errorf("Got %v,%v expected %v,%v", actual, loaded, 10, true)
+	}
+
+	if swapped := CompareAndSwap(tree, 1, 20, 30); swapped {
+		t.Errorf("Got %v expected %v", swapped, false)
+	}
+	if swapped := CompareAndSwap(tree, 1, 10, 30); !swapped {
+		t.Errorf("Got %v expected %v", swapped, true)
+	}
+	if swapped := CompareAndSwap(tree, 2, 0, 30); swapped {
+		t.Errorf("Got %v expected %v", swapped, false)
+	}
+
+	lists := NewSync[int, []int](3)
+	lists.Insert(1, []int{1, 2})
+	equal := func(a, b []int) bool {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	if swapped := lists.CompareAndSwapFunc(1, []int{1, 3}, []int{3}, equal); swapped {
+		t.Errorf("Got %v expected %v", swapped, false)
+	}
+	if swapped := lists.CompareAndSwapFunc(1, []int{1, 2}, []int{3}, equal); !swapped {
+		t.Errorf("Got %v expected %v", swapped, true)
+	}
+	if value, _ := lists.Get(1); fmt.Sprint(value) != "[3]" {
+		t.Errorf("Got %v expected %v", value, "[3]")
+	}
+
+	increment := func(old int, found bool) (int, bool) {
+		return old + 1, true
+	}
+	tree.Update(1, increment)
+	tree.Update(2, increment)
+	if value, found := tree.Get(1); value != 31 || !found {
+		t.Errorf("Got %v expected %v", value, 31)
+	}
+	if value, found := tree.Get(2); value != 1 || !found {
+		t.Errorf("Got %v expected %v", value, 1)
+	}
+
+	tree.Update(2, func(old int, found bool) (int, bool) {
+		return 0, false
+	})
+	if value, found := tree.Get(2); found {
+		t.Errorf("Got %v expected %v", value, "<nil>")
+	}
+	if actualValue := tree.Size(); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
+
+func TestBTreeSyncConcurrent(t *testing.T) {
+	tree := NewSync[int, int](3)
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, 0)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tree.Update(i%100, func(old int, found bool) (int, bool) {
+					return old + 1, true
+				})
+				tree.Insert(100+i%50, i)
+				tree.Remove(100 + i%50)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				// iterate without holding the lock, writers keep going meanwhile
+				it := tree.Iterator()
+				previous := -1
+				for it.Next() {
+					if it.Key() <= previous {
+						t.Errorf("Got %v after %v", it.Key(), previous)
+					}
+					previous = it.Key()
+					tree.Get(it.Key())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sum := 0
+	for _, value := range tree.Values() {
+		sum += value
+	}
+	if actualValue, expectedValue := sum, 4000; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := tree.Size(), 100; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+// assertBTree checks fill bounds, uniform leaf depth, key order and parent links of every node
+func assertBTree(t *testing.T, tree *Tree[int, int]) {
+	leafDepth := -1
+	count := 0
+	var walk func(node *Node[int, int], parent *Node[int, int], depth int, lo, hi int)
+	walk = func(node *Node[int, int], parent *Node[int, int], depth int, lo, hi int) {
+		if node.Parent != parent {
+			t.Fatalf("Wrong parent of node %v", node.Entries)
+		}
+		if len(node.Entries) > tree.maxEntries() || node != tree.Root && len(node.Entries) < tree.minEntries() {
+			t.Fatalf("Got %v entries in node %v", len(node.Entries), node.Entries)
+		}
+		for i, entry := range node.Entries {
+			if entry.Key <= lo || entry.Key >= hi || i > 0 && entry.Key <= node.Entries[i-1].Key {
+				t.Fatalf("Node %v is out of order", node.Entries)
+			}
+		}
+		count += len(node.Entries)
+		if tree.isLeaf(node) {
+			if leafDepth < 0 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				t.Fatalf("Got leaf depth %v and %v", leafDepth, depth)
+			}
+			return
+		}
+		if len(node.Children) != len(node.Entries)+1 {
+			t.Fatalf("Got %v children for %v entries", len(node.Children), len(node.Entries))
+		}
+		for i, child := range node.Children {
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = node.Entries[i-1].Key
+			}
+			if i < len(node.Entries) {
+				childHi = node.Entries[i].Key
+			}
+			walk(child, node, depth+1, childLo, childHi)
+		}
+	}
+	if tree.Root != nil {
+		walk(tree.Root, nil, 0, math.MinInt, math.MaxInt)
+	}
+	if count != tree.Size() {
+		t.Fatalf("Got %v entries expected %v", count, tree.Size())
+	}
+}
+
+func TestBTreeValidate(t *testing.T) {
+	tree := New[int, int](3)
+	if err := tree.Validate(); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, i)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+
+	corruptions := []func(tree *Tree[int, int]){
+		func(tree *Tree[int, int]) {
+			tree.Left().Entries = append(tree.Left().Entries, Entry[int, int]{Key: 1000})
+		},
+		func(tree *Tree[int, int]) { tree.Left().Entries = tree.Left().Entries[:0] },
+		func(tree *Tree[int, int]) { tree.Left().Entries[0].Key = 1000 },
+		func(tree *Tree[int, int]) { tree.Right().Parent = tree.Root },
+		func(tree *Tree[int, int]) { tree.Root.Children = tree.Root.Children[:1] },
+		func(tree *Tree[int, int]) {
+			leaf := tree.Left()
+			leaf.Children = []*Node[int, int]{{Parent: leaf, Entries: []Entry[int, int]{{Key: -2}}}, {Parent: leaf, Entries: []Entry[int, int]{{Key: 0}}}}
+			leaf.Entries = []Entry[int, int]{{Key: -1}}
+		},
+		func(tree *Tree[int, int]) { tree.size++ },
+	}
+	for i, corrupt := range corruptions {
+		tree := New[int, int](3)
+		for i := 0; i < 100; i++ {
+			tree.Insert(i, i)
+		}
+		corrupt(tree)
+		if err := tree.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Got %v expected %v for corruption %v", err, ErrInvalid, i)
+		}
+	}
+}
+
+func FuzzBTree(f *testing.F) {
+	f.Add(uint8(3), []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	f.Add(uint8(4), []byte{9, 8, 7, 6, 5, 4, 3, 2, 1, 0, 128, 130, 135, 129})
+	f.Add(uint8(5), []byte{3, 3, 131, 3, 131, 200, 72, 200})
+
+	f.Fuzz(func(t *testing.T, order uint8, ops []byte) {
+		tree := New[int, int](3 + int(order%8))
+		expected := map[int]int{}
+
+		// the high bit of a byte removes the key held by the low bits, otherwise it is inserted
+		for i, op := range ops {
+			key := int(op & 0x7f)
+			if op&0x80 != 0 {
+				tree.Remove(key)
+				delete(expected, key)
+			} else {
+				tree.Insert(key, i)
+				expected[key] = i
+			}
+
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("Got error %v after op %v", err, i)
+			}
+		}
+
+		for key, value := range expected {
+			if actualValue, found := tree.Get(key); actualValue != value || !found {
+				t.Fatalf("Got %v,%v expected %v,%v", actualValue, found, value, true)
+			}
+		}
+
+		// bulk loading the same content gives a valid tree too
+		entries := make([]Entry[int, int], 0, len(expected))
+		for it := tree.Iterator(); it.Next(); {
+			entries = append(entries, Entry[int, int]{Key: it.Key(), Value: it.Value()})
+		}
+		if err := tree.BulkLoad(entries, float64(order%10+1)/10); err != nil {
+			t.Fatalf("Got error %v", err)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("Got error %v after bulk load", err)
+		}
+	})
+}
+
+func TestBTreeBulkLoad(t *testing.T) {
+	for _, order := range []int{3, 4, 5, 7, 16} {
+		for _, fillFactor := range []float64{0.1, 0.5, 0.7, 1} {
+			for size := 0; size <= 200; size++ {
+				entries := make([]Entry[int, int], size)
+				for i := range entries {
+					entries[i] = Entry[int, int]{Key: i * 2, Value: i}
+				}
+
+				tree := New[int, int](order)
+				tree.Insert(-1, -1)
+				if err := tree.BulkLoad(entries, fillFactor); err != nil {
+					t.Fatalf("Got error %v", err)
+				}
+				assertBTree(t, tree)
+
+				if actualValue, expectedValue := tree.Size(), size; actualValue != expectedValue {
+					t.Fatalf("Got %v expected %v", actualValue, expectedValue)
+				}
+				for i, key := range tree.Keys() {
+					if key != i*2 {
+						t.Fatalf("Got %v expected %v", key, i*2)
+					}
+				}
+
+				// tree keeps working after loading
+				for i := 0; i < size; i++ {
+					tree.Insert(i*2+1, i)
+				}
+				assertBTree(t, tree)
+				for i := 0; i < size; i++ {
+					tree.Remove(i * 2)
+				}
+				assertBTree(t, tree)
+				if actualValue, expectedValue := tree.Size(), size; actualValue != expectedValue {
+					t.Fatalf("Got %v expected %v", actualValue, expectedValue)
+				}
+			}
+		}
+	}
+}
+
+func TestBTreeBulkLoadFillFactor(t *testing.T) {
+	entries := make([]Entry[int, int], 1000)
+	for i := range entries {
+		entries[i] = Entry[int, int]{Key: i, Value: i}
+	}
+
+	full, half := New[int, int](5), New[int, int](5)
+	if err := full.BulkLoad(entries, 1); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if err := half.BulkLoad(entries, 0.5); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := len(full.Left().Entries), 4; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := len(half.Right().Entries), 2; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if full.Height() >= half.Height() {
+		t.Errorf("Got height %v for full nodes and %v for half filled nodes", full.Height(), half.Height())
+	}
+}
+
+func TestBTreeBulkLoadUnsorted(t *testing.T) {
+	tree := New[int, string](3)
+	tree.Insert(1, "a")
+
+	tests := [][]Entry[int, string]{
+		{{1, "a"}, {3, "c"}, {2, "b"}},
+		{{1, "a"}, {2, "b"}, {2, "b"}},
+	}
+	for _, entries := range tests {
+		if err := tree.BulkLoad(entries, 1); !errors.Is(err, ErrUnsorted) {
+			t.Errorf("Got %v expected %v", err, ErrUnsorted)
+		}
+	}
+	if err := tree.BulkLoad([]Entry[int, string]{{1, "a"}}, 0); err == nil {
+		t.Errorf("Got %v expected error", err)
+	}
+
+	// untouched
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
 func TestBTreeSerialization(t *testing.T) {
 	tree := NewWithStringComparator(3)
 	tree.Insert("c", "3")
@@ -1114,103 +1594,921 @@ func TestBTreeSerialization(t *testing.T) {
 	assert()
 }
 
-func benchmarkGet(b *testing.B, tree *Tree, size int) {
-	for i := 0; i < b.N; i++ {
-		for n := 0; n < size; n++ {
-			tree.Get(n)
-		}
+func TestBTreeEnumerable(t *testing.T) {
+	tree := New[int, string](3)
+	for i := 10; i > 0; i-- {
+		tree.Insert(i, fmt.Sprint(i))
 	}
-}
 
-func benchmarkInsert(b *testing.B, tree *Tree, size int) {
-	for i := 0; i < b.N; i++ {
-		for n := 0; n < size; n++ {
-			tree.Insert(n, struct{}{})
-		}
+	keys := ""
+	tree.Each(func(key int, value string) bool {
+		keys += value + " "
+		return key < 4
+	})
+	if actualValue, expectedValue := keys, "1 2 3 4 "; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
 	}
-}
 
-func benchmarkRemove(b *testing.B, tree *Tree, size int) {
-	for i := 0; i < b.N; i++ {
-		for n := 0; n < size; n++ {
-			tree.Remove(n)
-		}
+	mapped := tree.Map(func(key int, value string) (int, string) {
+		return -key, value + "!"
+	})
+	if actualValue, expectedValue := fmt.Sprint(mapped.Keys(), mapped.Values()[0]), "[-10 -9 -8 -7 -6 -5 -4 -3 -2 -1]10!"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
 	}
-}
 
-func BenchmarkBTreeGet100(b *testing.B) {
-	b.StopTimer()
-	size := 100
-	tree := NewWithIntComparator(128)
-	for n := 0; n < size; n++ {
-		tree.Insert(n, struct{}{})
+	even := tree.Filter(func(key int, value string) bool {
+		return key%2 == 0
+	})
+	if actualValue, expectedValue := fmt.Sprint(even.Keys(), even.Values()), "[2 4 6 8 10] [2 4 6 8 10]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if err := even.Validate(); err != nil {
+		t.Errorf("Got error %v", err)
 	}
-	b.StartTimer()
-	benchmarkGet(b, tree, size)
-}
 
-func BenchmarkBTreeGet1000(b *testing.B) {
-	b.StopTimer()
-	size := 1000
-	tree := NewWithIntComparator(128)
-	for n := 0; n < size; n++ {
-		tree.Insert(n, struct{}{})
+	// the result keeps decoding JSON keys into the comparator's type
+	ints := NewWithIntComparator(3)
+	for i := 1; i <= 5; i++ {
+		ints.Insert(i, i)
+	}
+	filtered := ints.Filter(func(key, value interface{}) bool { return key.(int) > 2 })
+	data, _ := filtered.ToJSON()
+	if err := filtered.FromJSON(data); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(filtered.Keys()), "[3 4 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
 	}
-	b.StartTimer()
-	benchmarkGet(b, tree, size)
-}
 
-func BenchmarkBTreeGet10000(b *testing.B) {
-	b.StopTimer()
-	size := 10000
-	tree := NewWithIntComparator(128)
-	for n := 0; n < size; n++ {
-		tree.Insert(n, struct{}{})
+	// nodes are left room, so inserting into the filtered tree doesn't split them right away
+	wide := New[int, int](5)
+	for i := 0; i < 200; i++ {
+		wide.Insert(i, i)
+	}
+	var full func(node *Node[int, int]) bool
+	full = func(node *Node[int, int]) bool {
+		for _, child := range node.Children {
+			if full(child) {
+				return true
+			}
+		}
+		return len(node.Entries) == wide.maxEntries()
+	}
+	if filtered := wide.Filter(func(key, value int) bool { return key%3 != 0 }); full(filtered.Root) {
+		t.Errorf("Got %v expected %v", true, false)
 	}
-	b.StartTimer()
-	benchmarkGet(b, tree, size)
-}
 
-func BenchmarkBTreeGet100000(b *testing.B) {
-	b.StopTimer()
-	size := 100000
-	tree := NewWithIntComparator(128)
-	for n := 0; n < size; n++ {
-		tree.Insert(n, struct{}{})
+	visited := 0
+	if !tree.Any(func(key int, value string) bool { visited++; return key > 2 }) || visited != 3 {
+		t.Errorf("Got %v visited expected %v", visited, 3)
+	}
+	if tree.Any(func(key int, value string) bool { return key > 10 }) {
+		t.Errorf("Got %v expected %v", true, false)
+	}
+	if !even.All(func(key int, value string) bool { return key%2 == 0 }) {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+	visited = 0
+	if tree.All(func(key int, value string) bool { visited++; return key < 5 }) || visited != 5 {
+		t.Errorf("Got %v visited expected %v", visited, 5)
+	}
+	if key, value, found := tree.Find(func(key int, value string) bool { return key*key > 20 }); key != 5 || value != "5" || !found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, 5, "5", true)
+	}
+	if key, value, found := tree.Find(func(key int, value string) bool { return false }); key != 0 || value != "" || found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, 0, "", false)
 	}
-	b.StartTimer()
-	benchmarkGet(b, tree, size)
-}
 
-func BenchmarkBTreeInsert100(b *testing.B) {
-	b.StopTimer()
-	size := 100
-	tree := NewWithIntComparator(128)
-	b.StartTimer()
-	benchmarkInsert(b, tree, size)
+	joined := Reduce(tree, "", func(accumulator string, key int, value string) string {
+		return accumulator + value
+	})
+	if actualValue, expectedValue := joined, "12345678910"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := Reduce(New[int, string](3), 0, func(sum int, key int, value string) int { return sum + key }), 0; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
 }
 
-func BenchmarkBTreeInsert1000(b *testing.B) {
-	b.StopTimer()
-	size := 1000
-	tree := NewWithIntComparator(128)
-	for n := 0; n < size; n++ {
-		tree.Insert(n, struct{}{})
+func TestBTreeGeneric(t *testing.T) {
+	tree := New[int, string](3)
+	if actualValue, expectedValue := tree.LeftKey(), 0; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := tree.RightValue(), ""; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
 	}
-	b.StartTimer()
-	benchmarkInsert(b, tree, size)
-}
 
-func BenchmarkBTreeInsert10000(b *testing.B) {
-	b.StopTimer()
-	size := 10000
-	tree := NewWithIntComparator(128)
-	for n := 0; n < size; n++ {
-		tree.Insert(n, struct{}{})
+	for i := 1; i <= 7; i++ {
+		tree.Insert(i, string(rune('a'+i-1)))
 	}
-	b.StartTimer()
-	benchmarkInsert(b, tree, size)
-}
+	tree.Insert(1, "x") // overwrite
+
+	if actualValue, expectedValue := tree.Height(), 3; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 3 4 5 6 7]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Values()), "[x b c d e f g]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := tree.LeftKey(), 1; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := tree.RightKey(), 7; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := tree.Get(8); value != "" || found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, "", false)
+	}
+
+	tree.Remove(4)
+	tree.Remove(1)
+	it := tree.Iterator()
+	keys := ""
+	for it.Last(); ; {
+		keys += fmt.Sprint(it.Key())
+		if !it.Previous() {
+			break
+		}
+	}
+	if actualValue, expectedValue := keys, "76532"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := tree.Size(), 5; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestBTreeGenericWithFunc(t *testing.T) {
+	// order by length, then lexical
+	tree := NewWithFunc[string, int](3, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+	tree.Insert("ccc", 3)
+	tree.Insert("a", 1)
+	tree.Insert("bb", 2)
+	tree.Insert("ab", 2)
+
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[a ab bb ccc]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestBTreeGenericFromJSON(t *testing.T) {
+	tree := New[int, string](3)
+	for i := 10; i > 0; i-- {
+		tree.Insert(i, fmt.Sprint(i))
+	}
+
+	data, err := tree.ToJSON()
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	// keys keep their type and order
+	loaded := New[int, string](3)
+	if err := loaded.FromJSON(data); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(loaded.Keys(), loaded.Values()), fmt.Sprint(tree.Keys(), tree.Values()); actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if err := loaded.FromJSON([]byte(`{"1":"a"}`)); err == nil {
+		t.Errorf("Got %v expected %v", err, "error")
+	}
+
+	// the comparator constructors decode keys into the type of their comparator
+	ints := NewWithIntComparator(3)
+	if err := ints.FromJSON([]byte(`[{"key":2,"value":"b"},{"key":1,"value":"a"}]`)); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if value, found := ints.Get(2); value != "b" || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, "b", true)
+	}
+
+	// without KeyDecoder numbers are decoded as float64 into interface{} keys, which IntComparator can't compare
+	ints = NewWith(3, comparator.IntComparator)
+	ints.Insert(1, "a")
+	if err := ints.FromJSON([]byte(`[{"key":1,"value":"a"},{"key":2,"value":"b"}]`)); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+	if actualValue, expectedValue := fmt.Sprint(ints.Keys()), "[1]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestBTreeAutoComparator(t *testing.T) {
+	tree := NewWithAutoComparator(3)
+	for i, key := range []interface{}{"b", int64(9007199254740993), 2.5, true, -1, nil, uint8(3)} {
+		tree.Insert(key, i)
+	}
+
+	data, err := tree.ToJSON()
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	// numbers of any kind are ordered numerically, large integers are kept exact
+	loaded := NewWithAutoComparator(3)
+	if err := loaded.FromJSON(data); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(loaded.Keys()), "[<nil> true -1 2.5 3 9007199254740993 b]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := loaded.Get(int64(9007199254740993)); value != 1.0 || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 1.0, true)
+	}
+	if value, found := loaded.Get(3); value != 6.0 || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 6.0, true)
+	}
+
+	// keys Auto doesn't order are reported and leave the tree untouched
+	if err := loaded.FromJSON([]byte(`[{"key":1,"value":1},{"key":[1],"value":2}]`)); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+	if actualValue, expectedValue := loaded.Size(), 7; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestBTreeBinary(t *testing.T) {
+	tree := New[int, string](3)
+	tree.KeyCodec, tree.ValueCodec = codec.Int, codec.String
+	for i := 100; i > 0; i-- {
+		tree.Insert(i*7%101, fmt.Sprint(i))
+	}
+
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	// order is taken from the header when the tree has none
+	loaded := &Tree[int, string]{Comparator: cmp.Compare[int], KeyCodec: codec.Int, ValueCodec: codec.String}
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := loaded.m, 3; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(loaded.Keys()), fmt.Sprint(tree.Keys()); actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(loaded.Values()), fmt.Sprint(tree.Values()); actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// default gob codecs
+	tree.KeyCodec, tree.ValueCodec = nil, nil
+	var buffer bytes.Buffer
+	if _, err := tree.WriteTo(&buffer); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	loaded = New[int, string](5)
+	if _, err := loaded.ReadFrom(&buffer); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(loaded.Keys()), fmt.Sprint(tree.Keys()); actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestBTreeBinaryInterface(t *testing.T) {
+	tree := NewWithIntComparator(3)
+	tree.Insert(2, "b")
+	tree.Insert(1, 1.5)
+	tree.Insert(3, []string{"c"})
+	gob.Register([]string{})
+
+	// the tree encodes itself through encoding.BinaryMarshaler
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(tree); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	loaded := NewWithIntComparator(3)
+	if err := gob.NewDecoder(&buffer).Decode(loaded); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	for _, key := range []interface{}{1, 2, 3} {
+		value, found := loaded.Get(key)
+		if expected, _ := tree.Get(key); !found || fmt.Sprintf("%T %v", value, value) != fmt.Sprintf("%T %v", expected, expected) {
+			t.Errorf("Got %T %v expected %T %v", value, value, expected, expected)
+		}
+	}
+}
+
+func TestBTreeBinaryInvalid(t *testing.T) {
+	tree := New[int, int](3)
+	tree.KeyCodec, tree.ValueCodec = codec.Int, codec.Int
+	tree.Insert(1, 1)
+	tree.Insert(2, 2)
+	data, _ := tree.MarshalBinary()
+
+	version := append([]byte{}, data...)
+	version[4] = 2
+	if err := tree.UnmarshalBinary(version); !errors.Is(err, ErrFormat) {
+		t.Errorf("Got %v expected %v", err, ErrFormat)
+	}
+	if err := tree.UnmarshalBinary([]byte("JSON{}")); !errors.Is(err, ErrFormat) {
+		t.Errorf("Got %v expected %v", err, ErrFormat)
+	}
+	if err := tree.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrFormat) {
+		t.Errorf("Got %v expected %v", err, ErrFormat)
+	}
+
+	// keys out of order for the comparator of the reading tree
+	reversed := NewWithFunc[int, int](3, func(a, b int) int { return b - a })
+	reversed.KeyCodec, reversed.ValueCodec = codec.Int, codec.Int
+	if err := reversed.UnmarshalBinary(data); !errors.Is(err, ErrUnsorted) {
+		t.Errorf("Got %v expected %v", err, ErrUnsorted)
+	}
+
+	// untouched
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// the order of the header isn't taken when reading fails
+	unordered := &Tree[int, int]{Comparator: cmp.Compare[int], KeyCodec: codec.Int, ValueCodec: codec.Int}
+	if err := unordered.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrFormat) {
+		t.Errorf("Got %v expected %v", err, ErrFormat)
+	}
+	if actualValue, expectedValue := unordered.m, 0; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// a tree allocated by gob has no comparator
+	var buffer bytes.Buffer
+	gob.NewEncoder(&buffer).Encode(struct{ T *Tree[int, int] }{T: tree})
+	var decoded struct{ T *Tree[int, int] }
+	if err := gob.NewDecoder(bytes.NewReader(buffer.Bytes())).Decode(&decoded); err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+	decoded.T = New[int, int](3)
+	decoded.T.KeyCodec, decoded.T.ValueCodec = codec.Int, codec.Int
+	if err := gob.NewDecoder(bytes.NewReader(buffer.Bytes())).Decode(&decoded); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(decoded.T.Keys()), "[1 2]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestBTreeBinaryStream(t *testing.T) {
+	first, second := New[int, int](3), New[int, int](3)
+	for i := 0; i < 10; i++ {
+		first.Insert(i, i)
+		second.Insert(-i, i)
+	}
+
+	var buffer bytes.Buffer
+	first.WriteTo(&buffer)
+	second.WriteTo(&buffer)
+	buffer.WriteString("tail")
+	data := buffer.Bytes()
+
+	// trees following each other on a stream, read with and without io.ByteReader
+	for _, reader := range []io.Reader{bytes.NewReader(data), struct{ io.Reader }{bytes.NewReader(data)}} {
+		for _, tree := range []*Tree[int, int]{first, second} {
+			loaded := New[int, int](3)
+			if _, err := loaded.ReadFrom(reader); err != nil {
+				t.Fatalf("Got error %v", err)
+			}
+			if actualValue, expectedValue := fmt.Sprint(loaded.Keys()), fmt.Sprint(tree.Keys()); actualValue != expectedValue {
+				t.Errorf("Got %v expected %v", actualValue, expectedValue)
+			}
+		}
+
+		if tail, _ := io.ReadAll(reader); string(tail) != "tail" {
+			t.Errorf("Got %q expected %q", tail, "tail")
+		}
+	}
+}
+
+func TestBTreeDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+
+	tree, err := OpenDisk(path, 5, codec.Int, codec.String, WithPageSize(256), WithCacheSize(4))
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	expected := map[int]string{}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := random.Intn(1000)
+		if random.Intn(3) == 0 {
+			delete(expected, key)
+			err = tree.Remove(key)
+		} else {
+			expected[key] = fmt.Sprint(i)
+			err = tree.Insert(key, fmt.Sprint(i))
+		}
+		if err != nil {
+			t.Fatalf("Got error %v", err)
+		}
+	}
+	assertDiskTree(t, tree, expected)
+
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if err := tree.Insert(1, "a"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Got %v expected %v", err, ErrClosed)
+	}
+
+	// reopen, the page size is read from the file
+	tree, err = OpenDisk(path, 5, codec.Int, codec.String, WithCacheSize(2))
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	assertDiskTree(t, tree, expected)
+
+	// remove everything, freed pages are reused afterwards
+	for key := range expected {
+		if err := tree.Remove(key); err != nil {
+			t.Fatalf("Got error %v", err)
+		}
+	}
+	pages := tree.pager.meta.pages
+	for key, value := range expected {
+		if err := tree.Insert(key, value); err != nil {
+			t.Fatalf("Got error %v", err)
+		}
+	}
+	if actualValue, expectedValue := tree.pager.meta.pages, pages; actualValue > expectedValue {
+		t.Errorf("Got %v expected at most %v pages", actualValue, expectedValue)
+	}
+	assertDiskTree(t, tree, expected)
+	tree.Close()
+
+	if _, err := OpenDisk(path, 7, codec.Int, codec.String); err == nil {
+		t.Errorf("Got %v expected error", err)
+	}
+}
+
+func TestBTreeDiskIterator(t *testing.T) {
+	tree, err := OpenDiskWith(filepath.Join(t.TempDir(), "index"), 3, comparator.IntComparator, WithCacheSize(1))
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	defer tree.Close()
+
+	it := tree.Iterator()
+	if it.Next() || it.Last() {
+		t.Errorf("Got element of empty tree")
+	}
+
+	for i := 20; i > 0; i -= 2 {
+		tree.Insert(i, i*10)
+	}
+
+	keys := ""
+	for it.Last(); ; {
+		keys += fmt.Sprint(it.Key(), " ")
+		if !it.Previous() {
+			break
+		}
+	}
+	if actualValue, expectedValue := keys, "20 18 16 14 12 10 8 6 4 2 "; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// modifying the tree while iterating
+	it = tree.IteratorFrom(9)
+	keys = ""
+	for it.Next() {
+		keys += fmt.Sprint(it.Key(), " ")
+		tree.Remove(it.Key())
+		tree.Insert(it.Key().(int)-1, 0)
+	}
+	if actualValue, expectedValue := keys, "10 12 14 16 18 20 "; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+}
+
+func TestBTreeDiskEntryTooLarge(t *testing.T) {
+	tree, err := OpenDisk(filepath.Join(t.TempDir(), "index"), 4, codec.String, codec.Bytes, WithPageSize(128))
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	defer tree.Close()
+
+	if err := tree.Insert("a", make([]byte, 128)); !errors.Is(err, ErrEntryTooLarge) {
+		t.Errorf("Got %v expected %v", err, ErrEntryTooLarge)
+	}
+	if err := tree.Insert("a", make([]byte, 16)); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+	if value, found, err := tree.Get("a"); len(value) != 16 || !found || err != nil {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", value, found, err, make([]byte, 16), true, nil)
+	}
+
+	if _, err := OpenDisk(filepath.Join(t.TempDir(), "small"), 32, codec.String, codec.Bytes, WithPageSize(128)); err == nil {
+		t.Errorf("Got %v expected error", err)
+	}
+}
+
+func TestBTreeDiskInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+
+	tree, err := OpenDisk(path, 5, codec.Int, codec.String, WithPageSize(256))
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, fmt.Sprint(i))
+	}
+	tree.Close()
+
+	data, _ := os.ReadFile(path)
+
+	// page size, then order of the header
+	tests := []struct {
+		offset int
+		value  uint32
+	}{
+		{len(pagerMagic) + 1, 4},
+		{len(pagerMagic) + 1, 0},
+		{len(pagerMagic) + 1 + 4, 2},
+		{len(pagerMagic) + 1 + 4, 1000},
+	}
+
+	for _, test := range tests {
+		corrupted := append([]byte{}, data...)
+		binary.BigEndian.PutUint32(corrupted[test.offset:], test.value)
+		os.WriteFile(path, corrupted, 0644)
+
+		if _, err := OpenDisk(path, 5, codec.Int, codec.String); !errors.Is(err, ErrFormat) {
+			t.Errorf("Got %v expected %v for %v", err, ErrFormat, test)
+		}
+	}
+}
+
+// assertDiskTree checks the content of tree against expected and the B-tree properties of its pages
+func assertDiskTree(t *testing.T, tree *DiskTree[int, string], expected map[int]string) {
+	t.Helper()
+
+	if actualValue, expectedValue := tree.Size(), len(expected); actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	for key, value := range expected {
+		if actualValue, found, err := tree.Get(key); actualValue != value || !found || err != nil {
+			t.Fatalf("Got %v,%v,%v expected %v,%v,%v", actualValue, found, err, value, true, nil)
+		}
+	}
+
+	keys := []int{}
+	it := tree.Iterator()
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err := it.Err(); err != nil || len(keys) != len(expected) || !sort.IntsAreSorted(keys) {
+		t.Errorf("Got %v,%v expected %v sorted keys", len(keys), err, len(expected))
+	}
+
+	depth := -1
+	var walk func(id uint64, level int, root bool)
+	walk = func(id uint64, level int, root bool) {
+		node, err := tree.pager.fetch(id)
+		if err != nil {
+			t.Fatalf("Got error %v", err)
+		}
+		if count := len(node.entries); count > tree.maxEntries() || (!root && count < tree.minEntries()) {
+			t.Errorf("Got %v entries in page %v", count, id)
+		}
+		if node.leaf() {
+			if depth == -1 {
+				depth = level
+			} else if depth != level {
+				t.Errorf("Got leaf at depth %v expected %v", level, depth)
+			}
+			return
+		}
+		if len(node.children) != len(node.entries)+1 {
+			t.Errorf("Got %v children for %v entries", len(node.children), len(node.entries))
+		}
+		for _, child := range append([]uint64(nil), node.children...) {
+			walk(child, level+1, false)
+		}
+	}
+	if tree.pager.meta.root != 0 {
+		walk(tree.pager.meta.root, 0, true)
+	}
+}
+
+func TestBPlusTree(t *testing.T) {
+	tree := NewPlusWithIntComparator(3)
+	for _, key := range []int{5, 6, 7, 3, 4, 1, 2} {
+		tree.Insert(key, fmt.Sprint(key))
+	}
+	tree.Insert(1, "x") // overwrite
+
+	if actualValue, expectedValue := tree.Size(), 7; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 3 4 5 6 7]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Values()), "[x 2 3 4 5 6 7]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := tree.Get(4); value != "4" || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, "4", true)
+	}
+	if value, found := tree.Get(8); value != nil || found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, nil, false)
+	}
+
+	// values only live in leaves, separators are copies of keys
+	if actualValue, expectedValue := len(tree.Root.Entries), 0; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	leaves := 0
+	for leaf := tree.Left(); leaf != nil; leaf = leaf.Next {
+		leaves++
+	}
+	if actualValue, expectedValue := leaves, 4; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	for _, key := range []int{4, 1, 7, 8} {
+		tree.Remove(key)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[2 3 5 6]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	for _, key := range []int{2, 3, 5, 6} {
+		tree.Remove(key)
+	}
+	if tree.Root != nil || !tree.Empty() || tree.Height() != 0 {
+		t.Errorf("Got %v expected empty tree", tree)
+	}
+}
+
+func TestBPlusTreeRandom(t *testing.T) {
+	for _, order := range []int{3, 4, 5, 8} {
+		tree := NewPlus[int, int](order)
+		expected := map[int]int{}
+		random := rand.New(rand.NewSource(int64(order)))
+
+		for i := 0; i < 3000; i++ {
+			key := random.Intn(500)
+			if random.Intn(3) == 0 {
+				tree.Remove(key)
+				delete(expected, key)
+			} else {
+				tree.Insert(key, i)
+				expected[key] = i
+			}
+			if i%100 == 0 {
+				assertBPlusTree(t, tree, expected)
+			}
+		}
+		assertBPlusTree(t, tree, expected)
+	}
+}
+
+func TestBPlusTreeIterator(t *testing.T) {
+	tree := NewPlus[int, string](3)
+	it := tree.Iterator()
+	from := tree.IteratorFrom(1)
+	if it.Next() || it.Last() || from.Next() {
+		t.Errorf("Got element of empty tree")
+	}
+
+	for i := 2; i <= 20; i += 2 {
+		tree.Insert(i, fmt.Sprint(i))
+	}
+
+	keys := ""
+	for it.Last(); ; {
+		keys += fmt.Sprint(it.Key(), " ")
+		if !it.Previous() {
+			break
+		}
+	}
+	if actualValue, expectedValue := keys, "20 18 16 14 12 10 8 6 4 2 "; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if it.Next(); it.Key() != 2 || it.Value() != "2" {
+		t.Errorf("Got %v,%v expected %v,%v", it.Key(), it.Value(), 2, "2")
+	}
+
+	tests := [][]interface{}{
+		{0, "2 4 6 8 10 12 14 16 18 20 "},
+		{2, "2 4 6 8 10 12 14 16 18 20 "},
+		{9, "10 12 14 16 18 20 "},
+		{14, "14 16 18 20 "},
+		{20, "20 "},
+		{21, ""},
+	}
+	for _, test := range tests {
+		it := tree.IteratorFrom(test[0].(int))
+		keys := ""
+		for it.Next() {
+			keys += fmt.Sprint(it.Key(), " ")
+		}
+		if actualValue, expectedValue := keys, test[1]; actualValue != expectedValue {
+			t.Errorf("Got %v expected %v from %v", actualValue, expectedValue, test[0])
+		}
+	}
+
+	keys = ""
+	tree.Range(4, 12, false, true, func(key int, value string) bool {
+		keys += value + " "
+		return key < 10
+	})
+	if actualValue, expectedValue := keys, "6 8 10 "; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+// assertBPlusTree checks the content of tree against expected and the B+tree properties of its nodes
+func assertBPlusTree(t *testing.T, tree *PlusTree[int, int], expected map[int]int) {
+	t.Helper()
+
+	if actualValue, expectedValue := tree.Size(), len(expected); actualValue != expectedValue {
+		t.Fatalf("Got %v expected %v", actualValue, expectedValue)
+	}
+	for key, value := range expected {
+		if actualValue, found := tree.Get(key); actualValue != value || !found {
+			t.Fatalf("Got %v,%v expected %v,%v", actualValue, found, value, true)
+		}
+	}
+
+	// leaves reached from the root in order are the leaf chain
+	leaves := []*PlusNode[int, int]{}
+	depth := -1
+	var walk func(node *PlusNode[int, int], level int, lo, hi *int)
+	walk = func(node *PlusNode[int, int], level int, lo, hi *int) {
+		if node != tree.Root && (len(node.Entries) > tree.maxEntries() || len(node.Children) > tree.maxChildren()) {
+			t.Fatalf("Got overfull node %v", node)
+		}
+		if len(node.Children) == 0 {
+			if node != tree.Root && len(node.Entries) < tree.minEntries() {
+				t.Fatalf("Got %v entries in leaf", len(node.Entries))
+			}
+			if depth == -1 {
+				depth = level
+			} else if depth != level {
+				t.Fatalf("Got leaf at depth %v expected %v", level, depth)
+			}
+			for _, entry := range node.Entries {
+				if (lo != nil && entry.Key < *lo) || (hi != nil && entry.Key >= *hi) {
+					t.Fatalf("Got key %v out of separators", entry.Key)
+				}
+			}
+			leaves = append(leaves, node)
+			return
+		}
+		if (node != tree.Root && len(node.Children) < tree.minChildren()) || len(node.Children) != len(node.Keys)+1 {
+			t.Fatalf("Got %v children for %v keys", len(node.Children), len(node.Keys))
+		}
+		for i, child := range node.Children {
+			if child.Parent != node {
+				t.Fatalf("Got wrong parent of child %v", i)
+			}
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = &node.Keys[i-1]
+			}
+			if i < len(node.Keys) {
+				childHi = &node.Keys[i]
+			}
+			walk(child, level+1, childLo, childHi)
+		}
+	}
+	if tree.Root != nil {
+		walk(tree.Root, 0, nil, nil)
+	}
+
+	var prev *PlusNode[int, int]
+	for i, leaf := range leaves {
+		if leaf.Prev != prev || (i > 0 && prev.Next != leaf) {
+			t.Fatalf("Got broken leaf chain at %v", i)
+		}
+		prev = leaf
+	}
+	if prev != nil && prev.Next != nil {
+		t.Fatalf("Got leaf after the last one")
+	}
+
+	keys := tree.Keys()
+	if len(keys) != len(expected) || !sort.IntsAreSorted(keys) {
+		t.Fatalf("Got %v expected %v sorted keys", len(keys), len(expected))
+	}
+}
+
+func benchmarkGet(b *testing.B, tree *Tree[interface{}, interface{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Get(n)
+		}
+	}
+}
+
+func benchmarkInsert(b *testing.B, tree *Tree[interface{}, interface{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Insert(n, struct{}{})
+		}
+	}
+}
+
+func benchmarkRemove(b *testing.B, tree *Tree[interface{}, interface{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Remove(n)
+		}
+	}
+}
+
+func BenchmarkBTreeGet100(b *testing.B) {
+	b.StopTimer()
+	size := 100
+	tree := NewWithIntComparator(128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGet(b, tree, size)
+}
+
+func BenchmarkBTreeGet1000(b *testing.B) {
+	b.StopTimer()
+	size := 1000
+	tree := NewWithIntComparator(128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGet(b, tree, size)
+}
+
+func BenchmarkBTreeGet10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := NewWithIntComparator(128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGet(b, tree, size)
+}
+
+func BenchmarkBTreeGet100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := NewWithIntComparator(128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGet(b, tree, size)
+}
+
+func BenchmarkBTreeInsert100(b *testing.B) {
+	b.StopTimer()
+	size := 100
+	tree := NewWithIntComparator(128)
+	b.StartTimer()
+	benchmarkInsert(b, tree, size)
+}
+
+func BenchmarkBTreeInsert1000(b *testing.B) {
+	b.StopTimer()
+	size := 1000
+	tree := NewWithIntComparator(128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkInsert(b, tree, size)
+}
+
+func BenchmarkBTreeInsert10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := NewWithIntComparator(128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkInsert(b, tree, size)
+}
 
 func BenchmarkBTreeInsert100000(b *testing.B) {
 	b.StopTimer()
@@ -1266,3 +2564,276 @@ func BenchmarkBTreeRemove100000(b *testing.B) {
 	b.StartTimer()
 	benchmarkRemove(b, tree, size)
 }
+
+func benchmarkGenericGet(b *testing.B, tree *Tree[int, struct{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Get(n)
+		}
+	}
+}
+
+func benchmarkGenericInsert(b *testing.B, tree *Tree[int, struct{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Insert(n, struct{}{})
+		}
+	}
+}
+
+func benchmarkGenericRemove(b *testing.B, tree *Tree[int, struct{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Remove(n)
+		}
+	}
+}
+
+func BenchmarkBTreeGenericGet100(b *testing.B) {
+	b.StopTimer()
+	size := 100
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericGet(b, tree, size)
+}
+
+func BenchmarkBTreeGenericGet1000(b *testing.B) {
+	b.StopTimer()
+	size := 1000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericGet(b, tree, size)
+}
+
+func BenchmarkBTreeGenericGet10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericGet(b, tree, size)
+}
+
+func BenchmarkBTreeGenericGet100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericGet(b, tree, size)
+}
+
+func BenchmarkBTreeGenericInsert100(b *testing.B) {
+	b.StopTimer()
+	size := 100
+	tree := New[int, struct{}](128)
+	b.StartTimer()
+	benchmarkGenericInsert(b, tree, size)
+}
+
+func BenchmarkBTreeGenericInsert1000(b *testing.B) {
+	b.StopTimer()
+	size := 1000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericInsert(b, tree, size)
+}
+
+func BenchmarkBTreeGenericInsert10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericInsert(b, tree, size)
+}
+
+func BenchmarkBTreeGenericInsert100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericInsert(b, tree, size)
+}
+
+func BenchmarkBTreeGenericRemove100(b *testing.B) {
+	b.StopTimer()
+	size := 100
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericRemove(b, tree, size)
+}
+
+func BenchmarkBTreeGenericRemove1000(b *testing.B) {
+	b.StopTimer()
+	size := 1000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericRemove(b, tree, size)
+}
+
+func BenchmarkBTreeGenericRemove10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericRemove(b, tree, size)
+}
+
+func BenchmarkBTreeGenericRemove100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericRemove(b, tree, size)
+}
+
+func BenchmarkBPlusTreeScan100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := NewPlus[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		for it := tree.Iterator(); it.Next(); {
+		}
+	}
+}
+
+func BenchmarkBTreeScan100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := New[int, struct{}](128)
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		for it := tree.Iterator(); it.Next(); {
+		}
+	}
+}
+
+func BenchmarkBTreeBulkLoad100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	entries := make([]Entry[int, struct{}], size)
+	for n := 0; n < size; n++ {
+		entries[n] = Entry[int, struct{}]{Key: n}
+	}
+	tree := New[int, struct{}](128)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tree.BulkLoad(entries, 1)
+	}
+}
+
+func BenchmarkBTreeBulkInsert100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tree := New[int, struct{}](128)
+		for n := 0; n < size; n++ {
+			tree.Insert(n, struct{}{})
+		}
+	}
+}
+
+func TestBTreeTryMethods(t *testing.T) {
+
+	tree := NewWithIntComparator(3)
+
+	// the first key used to be accepted without comparison
+	if err := tree.TryInsert("a", "a"); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if actualValue := tree.Size(); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := tree.TryInsert(i, fmt.Sprint(i)); err != nil {
+			t.Errorf("Got %v expected %v", err, nil)
+		}
+	}
+
+	if err := tree.TryInsert(6.0, "6"); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if value, found, err := tree.TryGet(3); value != "3" || !found || err != nil {
+		t.Errorf("Got %v %v %v expected %v %v %v", value, found, err, "3", true, nil)
+	}
+
+	if value, found, err := tree.TryGet("3"); value != nil || found || !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v %v %v expected %v %v %v", value, found, err, nil, false, comparator.ErrKeyType)
+	}
+
+	if err := tree.TryRemove(int64(3)); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := tree.TryRemove(3); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 4 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	syncTree := NewSyncWith(3, comparator.StringComparator)
+
+	if err := syncTree.TryInsert(1, 1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := syncTree.TryInsert("a", 1); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+
+	if _, _, err := syncTree.TryGet(1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := syncTree.CheckKey(1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := syncTree.TryRemove("a"); err != nil || !syncTree.Empty() {
+		t.Errorf("Got %v %v expected %v %v", err, syncTree.Empty(), nil, true)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-18 11:26:40
+ * @Description  : bottom-up bulk loading of sorted entries
+ */
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrUnsorted is returned by BulkLoad when entries are not in strictly ascending key order
+var ErrUnsorted = errors.New("btree: entries are not sorted in ascending order")
+
+// BulkLoad replaces the content of the tree with entries in O(n).
+// Entries must be sorted in strictly ascending order by tree.Comparator (no duplicate keys),
+// otherwise ErrUnsorted is returned and the tree is left untouched.
+//
+// Nodes are built bottom-up, leaves first, each filled up to fillFactor (0, 1] of its capacity
+// as far as the B-tree properties allow, e.g. 1 packs nodes full for read-mostly trees
+// while a lower factor leaves room for later inserts without splitting.
+func (tree *Tree[K, V]) BulkLoad(entries []Entry[K, V], fillFactor float64) error {
+
+	if fillFactor <= 0 || fillFactor > 1 {
+		return fmt.Errorf("btree: invalid fill factor %v, should be in (0, 1]", fillFactor)
+	}
+
+	for i := 1; i < len(entries); i++ {
+		if tree.Comparator(entries[i-1].Key, entries[i].Key) >= 0 {
+			return fmt.Errorf("%w: key %v at index %d", ErrUnsorted, entries[i].Key, i)
+		}
+	}
+
+	tree.Clear()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	target := int(math.Ceil(fillFactor * float64(tree.maxEntries())))
+	if target < tree.minEntries() {
+		target = tree.minEntries()
+	}
+
+	if target < 1 {
+		target = 1
+	}
+
+	level, children := entries, []*Node[K, V](nil)
+
+	// pack a level into nodes and push the separators up until they fit into the root
+	for len(level) > tree.maxEntries() {
+		children, level = tree.pack(level, children, target)
+	}
+
+	root := &Node[K, V]{Entries: append([]Entry[K, V](nil), level...), Children: children}
+	setParent(children, root)
+
+	tree.Root = root
+	tree.size = len(entries)
+
+	return nil
+}
+
+// pack splits entries of one level into nodes of about target entries,
+// with a single separator entry between every two adjacent nodes which goes up to the parent level.
+// children are the nodes of the level below, handed out in order, one more than the entries of each node.
+func (tree *Tree[K, V]) pack(entries []Entry[K, V], children []*Node[K, V], target int) (nodes []*Node[K, V], separators []Entry[K, V]) {
+
+	// k nodes hold len(entries)-(k-1) entries, pick k close to the target
+	// while keeping every node between minEntries and maxEntries
+	total := len(entries) + 1
+	count := (total + target) / (target + 1)
+
+	if low := (total + tree.maxChildren() - 1) / tree.maxChildren(); count < low {
+		count = low
+	}
+
+	if high := total / tree.minChildren(); count > high {
+		count = high
+	}
+
+	perNode := len(entries) - (count - 1)
+	base, extra := perNode/count, perNode%count
+
+	nodes = make([]*Node[K, V], 0, count)
+	separators = make([]Entry[K, V], 0, count-1)
+
+	position, child := 0, 0
+
+	for i := 0; i < count; i++ {
+
+		size := base
+		if i < extra {
+			size++
+		}
+
+		// every node owns its entries, later inserts append to them
+		node := &Node[K, V]{Entries: append([]Entry[K, V](nil), entries[position:position+size]...)}
+		position += size
+
+		if children != nil {
+			node.Children = append([]*Node[K, V](nil), children[child:child+size+1]...)
+			setParent(node.Children, node)
+			child += size + 1
+		}
+
+		nodes = append(nodes, node)
+
+		if i < count-1 {
+			separators = append(separators, entries[position])
+			position++
+		}
+	}
+
+	return nodes, separators
+}
@@ -0,0 +1,582 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-24 11:02:38
+ * @Description  : disk-backed B-tree
+ */
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Jayj1997/go-common/codec"
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+// DiskTree is a B-tree whose nodes are fixed-size pages of a file,
+// only the recently used pages are kept in memory, so it may hold more data than fits in RAM.
+//
+// Changes are written back when pages are evicted from the cache and made durable by Sync,
+// a crash between two Syncs may leave the file inconsistent.
+// DiskTree is not safe for concurrent use.
+type DiskTree[K, V any] struct {
+	Comparator func(a, b K) int // key comparator
+	pager      *pager[K, V]
+	m          int // order (maximum number of children)
+	maxEntry   int // maximum encoded size of an entry so that full nodes fit into a page
+}
+
+// DiskOptions configures a DiskTree
+type DiskOptions struct {
+	// size of a page in bytes, only used when creating the file
+	PageSize int
+	// maximum number of pages kept in memory
+	CacheSize int
+}
+
+// DiskOption sets an option of DiskTree
+type DiskOption func(*DiskOptions)
+
+// WithPageSize sets the page size of a new file
+func WithPageSize(size int) DiskOption {
+	return func(options *DiskOptions) {
+		options.PageSize = size
+	}
+}
+
+// WithCacheSize sets the maximum number of pages kept in memory
+func WithCacheSize(size int) DiskOption {
+	return func(options *DiskOptions) {
+		options.CacheSize = size
+	}
+}
+
+// OpenDisk opens or creates the B-tree file at path with order(maximum number of children) over an ordered key type.
+// Keys and values are encoded by the given codecs, gob if nil,
+// every entry must fit into a page together with the other order-2 entries of its node.
+func OpenDisk[K cmp.Ordered, V any](path string, order int, keys codec.Codec[K], values codec.Codec[V], options ...DiskOption) (*DiskTree[K, V], error) {
+	return OpenDiskWithFunc(path, order, cmp.Compare[K], keys, values, options...)
+}
+
+// OpenDiskWith opens or creates the B-tree file at path with order(maximum number of children) and costom key comparator,
+// i.e. keys and values are of type interface{} and encoded by gob.
+func OpenDiskWith(path string, order int, comparator comparator.Comparator, options ...DiskOption) (*DiskTree[interface{}, interface{}], error) {
+	return OpenDiskWithFunc[interface{}, interface{}](path, order, comparator, nil, nil, options...)
+}
+
+// OpenDiskWithFunc opens or creates the B-tree file at path with order(maximum number of children) and typed key compare function,
+// an existing file should be opened with the order and comparator it was created with.
+func OpenDiskWithFunc[K, V any](path string, order int, compare func(a, b K) int, keys codec.Codec[K], values codec.Codec[V], options ...DiskOption) (*DiskTree[K, V], error) {
+
+	if order < 3 || order > math.MaxUint16 {
+		return nil, fmt.Errorf("btree: invalid order %d, should be in [3, %d]", order, math.MaxUint16)
+	}
+
+	config := DiskOptions{PageSize: DefaultPageSize, CacheSize: DefaultCacheSize}
+	for _, option := range options {
+		option(&config)
+	}
+
+	if config.CacheSize < 1 {
+		return nil, fmt.Errorf("btree: invalid cache size %d", config.CacheSize)
+	}
+
+	if keys == nil {
+		keys = codec.Gob[K]()
+	}
+
+	if values == nil {
+		values = codec.Gob[V]()
+	}
+
+	if maxEntry(config.PageSize, order) < 1 || config.PageSize < headerSize {
+		return nil, fmt.Errorf("btree: page size %d too small for order %d", config.PageSize, order)
+	}
+
+	p, err := openPager(path, meta{pageSize: config.PageSize, order: order}, config.CacheSize, keys, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.meta.order != order {
+		p.file.Close()
+		return nil, fmt.Errorf("btree: %s was created with order %d, not %d", path, p.meta.order, order)
+	}
+
+	return &DiskTree[K, V]{Comparator: compare, pager: p, m: order, maxEntry: maxEntry(p.meta.pageSize, order)}, nil
+}
+
+// maxEntry returns the size an entry may take so that order-1 of them fit into a page next to order children
+func maxEntry(pageSize, order int) int {
+	return (pageSize - nodeHeaderSize - 8*order) / (order - 1)
+}
+
+// Insert inserts key-value pair into the tree.
+// If key already exists, then its value is updated with the new value.
+// ErrEntryTooLarge is returned if the encoded entry doesn't fit into a page.
+func (tree *DiskTree[K, V]) Insert(key K, value V) error {
+
+	if tree.pager == nil {
+		return ErrClosed
+	}
+
+	entry := Entry[K, V]{Key: key, Value: value}
+
+	encoded, err := tree.pager.appendEntry(nil, entry)
+	if err != nil {
+		return err
+	}
+
+	if len(encoded) > tree.maxEntry {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrEntryTooLarge, len(encoded), tree.maxEntry)
+	}
+
+	if err := tree.insert(entry); err != nil {
+		return err
+	}
+
+	return tree.pager.shrink()
+}
+
+// Get searches the key in the tree and returns its value,
+// second return parameter is true if key was found, otherwise false.
+func (tree *DiskTree[K, V]) Get(key K) (value V, found bool, err error) {
+
+	if tree.pager == nil {
+		return value, false, ErrClosed
+	}
+
+	path, _, err := tree.path(key)
+	if err != nil || path == nil {
+		return value, false, err
+	}
+
+	node := path[len(path)-1]
+	if index, found := tree.search(node, key); found {
+		value = node.entries[index].Value
+		return value, true, tree.pager.shrink()
+	}
+
+	return value, false, tree.pager.shrink()
+}
+
+// Remove removes the key from the tree, nothing happens if key is not in the tree
+func (tree *DiskTree[K, V]) Remove(key K) error {
+
+	if tree.pager == nil {
+		return ErrClosed
+	}
+
+	if err := tree.remove(key); err != nil {
+		return err
+	}
+
+	return tree.pager.shrink()
+}
+
+// Empty returns true if tree does not contain any keys
+func (tree *DiskTree[K, V]) Empty() bool {
+	return tree.Size() == 0
+}
+
+// Size returns number of keys in the tree
+func (tree *DiskTree[K, V]) Size() int {
+
+	if tree.pager == nil {
+		return 0
+	}
+
+	return tree.pager.meta.size
+}
+
+// Sync writes all changes back to the file and commits it to stable storage
+func (tree *DiskTree[K, V]) Sync() error {
+
+	if tree.pager == nil {
+		return ErrClosed
+	}
+
+	if err := tree.pager.flush(); err != nil {
+		return err
+	}
+
+	return tree.pager.file.Sync()
+}
+
+// Close syncs and closes the file, the tree is unusable afterwards
+func (tree *DiskTree[K, V]) Close() error {
+
+	if tree.pager == nil {
+		return ErrClosed
+	}
+
+	err := tree.Sync()
+	if closeErr := tree.pager.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	tree.pager = nil
+
+	return err
+}
+
+// path returns the pages from the root down to the page holding key or the leaf it would be inserted in,
+// together with the child index taken at every page but the last.
+func (tree *DiskTree[K, V]) path(key K) (path []*page[K, V], indexes []int, err error) {
+
+	id := tree.pager.meta.root
+
+	for id != 0 {
+
+		node, err := tree.pager.fetch(id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		path = append(path, node)
+
+		index, found := tree.search(node, key)
+		if found || node.leaf() {
+			break
+		}
+
+		indexes = append(indexes, index)
+		id = node.children[index]
+	}
+
+	return path, indexes, nil
+}
+
+func (tree *DiskTree[K, V]) insert(entry Entry[K, V]) error {
+
+	path, indexes, err := tree.path(entry.Key)
+	if err != nil {
+		return err
+	}
+
+	if path == nil {
+		root, err := tree.pager.allocate()
+		if err != nil {
+			return err
+		}
+
+		root.entries = []Entry[K, V]{entry}
+		tree.pager.meta.root = root.id
+		tree.pager.meta.size = 1
+
+		return nil
+	}
+
+	node := path[len(path)-1]
+	node.dirty = true
+
+	index, found := tree.search(node, entry.Key)
+	if found {
+		node.entries[index] = entry
+		return nil
+	}
+
+	node.entries = slices.Insert(node.entries, index, entry)
+	tree.pager.meta.size++
+
+	// split full pages bottom-up
+	for level := len(path) - 1; level >= 0 && len(path[level].entries) > tree.maxEntries(); level-- {
+
+		node := path[level]
+		middle := tree.middle()
+
+		right, err := tree.pager.allocate()
+		if err != nil {
+			return err
+		}
+
+		separator := node.entries[middle]
+		right.entries = slices.Clone(node.entries[middle+1:])
+		node.entries = slices.Clip(node.entries[:middle])
+
+		if !node.leaf() {
+			right.children = slices.Clone(node.children[middle+1:])
+			node.children = slices.Clip(node.children[:middle+1])
+		}
+
+		if level == 0 {
+			root, err := tree.pager.allocate()
+			if err != nil {
+				return err
+			}
+
+			root.entries = []Entry[K, V]{separator}
+			root.children = []uint64{node.id, right.id}
+			tree.pager.meta.root = root.id
+
+			break
+		}
+
+		parent, index := path[level-1], indexes[level-1]
+		parent.entries = slices.Insert(parent.entries, index, separator)
+		parent.children = slices.Insert(parent.children, index+1, right.id)
+		parent.dirty = true
+	}
+
+	return nil
+}
+
+func (tree *DiskTree[K, V]) remove(key K) error {
+
+	path, indexes, err := tree.path(key)
+	if err != nil || path == nil {
+		return err
+	}
+
+	node := path[len(path)-1]
+
+	index, found := tree.search(node, key)
+	if !found {
+		return nil
+	}
+
+	node.dirty = true
+
+	if node.leaf() {
+		node.entries = slices.Delete(node.entries, index, index+1)
+	} else {
+		// replace by the largest entry of the left sub-tree, then delete that one from its leaf
+		indexes = append(indexes, index)
+
+		for id := node.children[index]; ; {
+
+			child, err := tree.pager.fetch(id)
+			if err != nil {
+				return err
+			}
+
+			path = append(path, child)
+
+			if child.leaf() {
+				break
+			}
+
+			indexes = append(indexes, len(child.children)-1)
+			id = child.children[len(child.children)-1]
+		}
+
+		leaf := path[len(path)-1]
+		node.entries[index] = leaf.entries[len(leaf.entries)-1]
+		leaf.entries = leaf.entries[:len(leaf.entries)-1]
+		leaf.dirty = true
+	}
+
+	tree.pager.meta.size--
+
+	return tree.rebalance(path, indexes)
+}
+
+// rebalance restores the minimum number of entries bottom-up along path after a deletion,
+// borrowing from a sibling if it can spare an entry or merging with it otherwise.
+func (tree *DiskTree[K, V]) rebalance(path []*page[K, V], indexes []int) error {
+
+	for level := len(path) - 1; level > 0 && len(path[level].entries) < tree.minEntries(); level-- {
+
+		node, parent, index := path[level], path[level-1], indexes[level-1]
+		parent.dirty = true
+
+		var left, right *page[K, V]
+		var err error
+
+		if index > 0 {
+			if left, err = tree.pager.fetch(parent.children[index-1]); err != nil {
+				return err
+			}
+
+			if len(left.entries) > tree.minEntries() {
+				// rotate right through the parent
+				node.entries = slices.Insert(node.entries, 0, parent.entries[index-1])
+				parent.entries[index-1] = left.entries[len(left.entries)-1]
+				left.entries = left.entries[:len(left.entries)-1]
+
+				if !left.leaf() {
+					node.children = slices.Insert(node.children, 0, left.children[len(left.children)-1])
+					left.children = left.children[:len(left.children)-1]
+				}
+
+				left.dirty = true
+
+				return nil
+			}
+		}
+
+		if index < len(parent.children)-1 {
+			if right, err = tree.pager.fetch(parent.children[index+1]); err != nil {
+				return err
+			}
+
+			if len(right.entries) > tree.minEntries() {
+				// rotate left through the parent
+				node.entries = append(node.entries, parent.entries[index])
+				parent.entries[index] = right.entries[0]
+				right.entries = slices.Delete(right.entries, 0, 1)
+
+				if !right.leaf() {
+					node.children = append(node.children, right.children[0])
+					right.children = slices.Delete(right.children, 0, 1)
+				}
+
+				right.dirty = true
+
+				return nil
+			}
+		}
+
+		// merge with a sibling, pulling the separator down from the parent
+		if left != nil {
+			node, right, index = left, node, index-1
+		}
+
+		if right == nil {
+			return fmt.Errorf("%w: page %d has no sibling", ErrFormat, node.id)
+		}
+
+		node.entries = append(append(node.entries, parent.entries[index]), right.entries...)
+		node.children = append(node.children, right.children...)
+		node.dirty = true
+
+		parent.entries = slices.Delete(parent.entries, index, index+1)
+		parent.children = slices.Delete(parent.children, index+1, index+2)
+
+		if err := tree.pager.release(right); err != nil {
+			return err
+		}
+	}
+
+	// shrink the tree from the top
+	if root := path[0]; len(root.entries) == 0 {
+
+		next := uint64(0)
+		if !root.leaf() {
+			next = root.children[0]
+		}
+
+		if err := tree.pager.release(root); err != nil {
+			return err
+		}
+
+		tree.pager.meta.root = next
+	}
+
+	return nil
+}
+
+func (tree *DiskTree[K, V]) search(node *page[K, V], key K) (index int, found bool) {
+	return slices.BinarySearchFunc(node.entries, key, func(entry Entry[K, V], key K) int {
+		return tree.Comparator(entry.Key, key)
+	})
+}
+
+// floor returns the entry of the largest key smaller than key, an equal key is accepted when inclusive is true.
+// The tree is searched from the root, so it holds no page across calls.
+func (tree *DiskTree[K, V]) floor(key K, inclusive bool) (floor Entry[K, V], found bool, err error) {
+
+	for id := tree.pager.meta.root; id != 0; {
+
+		node, err := tree.pager.fetch(id)
+		if err != nil {
+			return floor, false, err
+		}
+
+		position, equal := tree.search(node, key)
+
+		if equal && inclusive {
+			return node.entries[position], true, nil
+		}
+
+		// entry left of the insert position (or equal entry) is smaller than key,
+		// and larger than any candidate found higher up the tree
+		if position > 0 {
+			floor, found = node.entries[position-1], true
+		}
+
+		if node.leaf() {
+			break
+		}
+
+		id = node.children[position]
+	}
+
+	return floor, found, nil
+}
+
+// ceiling returns the entry of the smallest key larger than key, an equal key is accepted when inclusive is true
+func (tree *DiskTree[K, V]) ceiling(key K, inclusive bool) (ceiling Entry[K, V], found bool, err error) {
+
+	for id := tree.pager.meta.root; id != 0; {
+
+		node, err := tree.pager.fetch(id)
+		if err != nil {
+			return ceiling, false, err
+		}
+
+		position, equal := tree.search(node, key)
+
+		if equal {
+			if inclusive {
+				return node.entries[position], true, nil
+			}
+
+			// skip the equal entry, go right of it
+			position++
+		}
+
+		if position < len(node.entries) {
+			ceiling, found = node.entries[position], true
+		}
+
+		if node.leaf() {
+			break
+		}
+
+		id = node.children[position]
+	}
+
+	return ceiling, found, nil
+}
+
+// edge returns the left-most (min) or right-most (max) entry
+func (tree *DiskTree[K, V]) edge(right bool) (edge Entry[K, V], found bool, err error) {
+
+	for id := tree.pager.meta.root; id != 0; {
+
+		node, err := tree.pager.fetch(id)
+		if err != nil {
+			return edge, false, err
+		}
+
+		index, child := 0, 0
+		if right {
+			index, child = len(node.entries)-1, len(node.children)-1
+		}
+
+		edge, found = node.entries[index], true
+
+		if node.leaf() {
+			break
+		}
+
+		id = node.children[child]
+	}
+
+	return edge, found, nil
+}
+
+func (tree *DiskTree[K, V]) minEntries() int {
+	return (tree.m+1)/2 - 1
+}
+
+func (tree *DiskTree[K, V]) maxEntries() int {
+	return tree.m - 1
+}
+
+func (tree *DiskTree[K, V]) middle() int {
+	return (tree.m - 1) / 2
+}
@@ -0,0 +1,174 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-24 15:20:44
+ * @Description  :
+ */
+package btree
+
+// DiskIterator holding the iterator's state over a DiskTree.
+// It keeps a copy of the current entry instead of page references and searches its neighbour from the root,
+// so pages may be evicted while iterating and the tree may be modified in between,
+// Next and Previous then continue from the current key.
+type DiskIterator[K, V any] struct {
+	tree     *DiskTree[K, V]
+	entry    Entry[K, V]
+	position position
+	err      error
+}
+
+// Iterator returns a stateful iterator whose elements are key/value pairs.
+func (tree *DiskTree[K, V]) Iterator() DiskIterator[K, V] {
+	return DiskIterator[K, V]{tree: tree, position: begin}
+}
+
+// IteratorFrom returns a stateful iterator positioned right before the ceiling of key,
+// so Next() fetches the smallest element whose key is larger than or equal to key.
+func (tree *DiskTree[K, V]) IteratorFrom(key K) DiskIterator[K, V] {
+
+	iterator := tree.Iterator()
+
+	if tree.pager == nil {
+		iterator.err = ErrClosed
+		return iterator
+	}
+
+	floor, found, err := tree.floor(key, false)
+	if err == nil {
+		err = tree.pager.shrink()
+	}
+
+	if found {
+		iterator.entry, iterator.position = floor, between
+	}
+
+	iterator.err = err
+
+	return iterator
+}
+
+// Next moves the iterator to the next element and returns true if there was a next element in the container.
+// If Next() returns true, then next element's key and value can be retrieved by Key() and Value().
+// If reading a page fails, Next() returns false and the error is reported by Err().
+// Modifies the state of the iterator
+func (iterator *DiskIterator[K, V]) Next() bool {
+
+	if iterator.position == end {
+		return false
+	}
+
+	var next Entry[K, V]
+	var found bool
+
+	iterator.step(func() (err error) {
+		if iterator.position == begin {
+			next, found, err = iterator.tree.edge(false)
+		} else {
+			next, found, err = iterator.tree.ceiling(iterator.entry.Key, false)
+		}
+		return err
+	})
+
+	if !found {
+		iterator.End()
+		return false
+	}
+
+	iterator.entry, iterator.position = next, between
+
+	return true
+}
+
+// Previous moves the iterator to the previous element and returns true if there was a previous element in the container.
+// If Previous() returns true, then previous element's key and value can be retrieved by Key() and Value().
+// If reading a page fails, Previous() returns false and the error is reported by Err().
+// Modifies the state of the iterator
+func (iterator *DiskIterator[K, V]) Previous() bool {
+
+	if iterator.position == begin {
+		return false
+	}
+
+	var previous Entry[K, V]
+	var found bool
+
+	iterator.step(func() (err error) {
+		if iterator.position == end {
+			previous, found, err = iterator.tree.edge(true)
+		} else {
+			previous, found, err = iterator.tree.floor(iterator.entry.Key, false)
+		}
+		return err
+	})
+
+	if !found {
+		iterator.Begin()
+		return false
+	}
+
+	iterator.entry, iterator.position = previous, between
+
+	return true
+}
+
+// step runs a search of the tree unless an error occurred before, keeping the first error
+func (iterator *DiskIterator[K, V]) step(search func() error) {
+
+	if iterator.err != nil {
+		return
+	}
+
+	if iterator.tree.pager == nil {
+		iterator.err = ErrClosed
+		return
+	}
+
+	if iterator.err = search(); iterator.err == nil {
+		iterator.err = iterator.tree.pager.shrink()
+	}
+}
+
+// Key returns the current element's key
+// Does not modify the state of the iterator
+func (iterator *DiskIterator[K, V]) Key() K {
+	return iterator.entry.Key
+}
+
+// Value returns the current element's value.
+// Does not modify the state of the iterator
+func (iterator *DiskIterator[K, V]) Value() V {
+	return iterator.entry.Value
+}
+
+// Err returns the first error met while reading pages, iteration stops at it
+func (iterator *DiskIterator[K, V]) Err() error {
+	return iterator.err
+}
+
+// Begin resets the iterator to its initial state (one-before-first)
+// Call Next() to fetch the first element if any.
+func (iterator *DiskIterator[K, V]) Begin() {
+	iterator.entry = Entry[K, V]{}
+	iterator.position = begin
+}
+
+// End moves the iterator past the last element (one-past-the-end)
+// Call Previous() to fetch the last element if any
+func (iterator *DiskIterator[K, V]) End() {
+	iterator.entry = Entry[K, V]{}
+	iterator.position = end
+}
+
+// First moves the iterator to the first element and
+// returns true if there was a first element in the container.
+// Modifies the state of the iterator
+func (iterator *DiskIterator[K, V]) First() bool {
+	iterator.Begin()
+	return iterator.Next()
+}
+
+// Last moves the iterator to the last element and returns true if there was a last element in the container.
+// Modifies the state of the iterator.
+func (iterator *DiskIterator[K, V]) Last() bool {
+	iterator.End()
+	return iterator.Previous()
+}
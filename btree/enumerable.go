@@ -0,0 +1,129 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-29 11:02:55
+ * @Description  : functional helpers over the entries in key order
+ */
+package btree
+
+// Each calls fn on every entry in ascending key order, iteration stops when fn returns false
+func (tree *Tree[K, V]) Each(fn func(key K, value V) bool) {
+	tree.each(tree.Root, fn)
+}
+
+// Map returns a new tree with the same order and comparator holding the key-value pairs returned by fn for every entry,
+// a key returned more than once keeps the value of the last (in key order of the source) entry.
+func (tree *Tree[K, V]) Map(fn func(key K, value V) (K, V)) *Tree[K, V] {
+
+	mapped := tree.empty()
+
+	tree.Each(func(key K, value V) bool {
+		mapped.Insert(fn(key, value))
+		return true
+	})
+
+	return mapped
+}
+
+// filterFillFactor is the fill factor of the trees built by Filter, which are usually changed afterwards
+const filterFillFactor = 0.75
+
+// Filter returns a new tree with the same order and comparator holding the entries for which fn returns true,
+// named like its rbt counterpart where Select is the order statistic.
+// The selected entries are already sorted, so the tree is bulk loaded in O(n),
+// with nodes left partly empty so the next inserts don't split them right away.
+// It panics if the comparator doesn't order the entries the tree holds strictly, i.e. it is inconsistent.
+func (tree *Tree[K, V]) Filter(fn func(key K, value V) bool) *Tree[K, V] {
+
+	var entries []Entry[K, V]
+
+	tree.Each(func(key K, value V) bool {
+		if fn(key, value) {
+			entries = append(entries, Entry[K, V]{Key: key, Value: value})
+		}
+		return true
+	})
+
+	selected := tree.empty()
+	if err := selected.BulkLoad(entries, filterFillFactor); err != nil {
+		panic(err)
+	}
+
+	return selected
+}
+
+// Any returns true if fn returns true for any entry, it stops at the first one
+func (tree *Tree[K, V]) Any(fn func(key K, value V) bool) bool {
+	_, _, found := tree.Find(fn)
+	return found
+}
+
+// All returns true if fn returns true for all entries (or the tree is empty), it stops at the first false
+func (tree *Tree[K, V]) All(fn func(key K, value V) bool) bool {
+
+	all := true
+
+	tree.Each(func(key K, value V) bool {
+		all = fn(key, value)
+		return all
+	})
+
+	return all
+}
+
+// Find returns the first entry in key order for which fn returns true,
+// third return parameter is false if there is none.
+func (tree *Tree[K, V]) Find(fn func(key K, value V) bool) (key K, value V, found bool) {
+
+	tree.Each(func(k K, v V) bool {
+		if fn(k, v) {
+			key, value, found = k, v, true
+		}
+		return !found
+	})
+
+	return key, value, found
+}
+
+// Reduce folds the entries in ascending key order into an accumulator starting from initial,
+// it's a function since methods can't introduce the accumulator's type parameter.
+func Reduce[K, V, A any](tree *Tree[K, V], initial A, fn func(accumulator A, key K, value V) A) A {
+
+	accumulator := initial
+
+	tree.Each(func(key K, value V) bool {
+		accumulator = fn(accumulator, key, value)
+		return true
+	})
+
+	return accumulator
+}
+
+// each walks the sub-tree rooted at node in-order, it returns false once fn did
+func (tree *Tree[K, V]) each(node *Node[K, V], fn func(key K, value V) bool) bool {
+
+	if node == nil {
+		return true
+	}
+
+	for i, entry := range node.Entries {
+
+		if !tree.isLeaf(node) && !tree.each(node.Children[i], fn) {
+			return false
+		}
+
+		if !fn(entry.Key, entry.Value) {
+			return false
+		}
+	}
+
+	if !tree.isLeaf(node) {
+		return tree.each(node.Children[len(node.Entries)], fn)
+	}
+
+	return true
+}
+
+// empty returns a new empty tree configured like tree
+func (tree *Tree[K, V]) empty() *Tree[K, V] {
+	return &Tree[K, V]{Comparator: tree.Comparator, KeyCodec: tree.KeyCodec, ValueCodec: tree.ValueCodec, KeyDecoder: tree.KeyDecoder, m: tree.m}
+}
@@ -7,10 +7,10 @@
 package btree
 
 // Iterator holding the iterator's state
-type Iterator struct {
-	tree     *Tree
-	node     *Node
-	entry    *Entry
+type Iterator[K, V any] struct {
+	tree     *Tree[K, V]
+	node     *Node[K, V]
+	entry    *Entry[K, V]
 	position position
 }
 
@@ -21,15 +21,29 @@ const (
 )
 
 // Iterator returns a stateful iterator whose elements are key/value pairs.
-func (tree *Tree) Iterator() Iterator {
-	return Iterator{tree: tree, node: nil, position: begin}
+func (tree *Tree[K, V]) Iterator() Iterator[K, V] {
+	return Iterator[K, V]{tree: tree, node: nil, position: begin}
+}
+
+// IteratorFrom returns a stateful iterator positioned right before the ceiling of key,
+// so Next() fetches the smallest element whose key is larger than or equal to key
+// without walking the elements in front of it.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) IteratorFrom(key K) Iterator[K, V] {
+
+	node, index, found := tree.floor(key, false)
+	if !found {
+		return tree.Iterator()
+	}
+
+	return Iterator[K, V]{tree: tree, node: node, entry: &node.Entries[index], position: between}
 }
 
 // Next moves the iterator to the next element and returns true if there was a next element int the container.
 // If Next() returns true, then next element's key and value can be retrieved by Key() and Value()
 // If Next() was called for the first time, then it will point the iterator to the first element if it exists.
 // Modifies the state of the iterator
-func (iterator *Iterator) Next() bool {
+func (iterator *Iterator[K, V]) Next() bool {
 
 	// If already at end, go to end
 	if iterator.position == end {
@@ -44,7 +58,7 @@ func (iterator *Iterator) Next() bool {
 		}
 
 		iterator.node = left
-		iterator.entry = left.Entries[0]
+		iterator.entry = &left.Entries[0]
 
 		goto between
 	}
@@ -61,7 +75,7 @@ func (iterator *Iterator) Next() bool {
 			}
 
 			// Return the left-most entry
-			iterator.entry = iterator.node.Entries[0]
+			iterator.entry = &iterator.node.Entries[0]
 
 			goto between
 		}
@@ -70,7 +84,7 @@ func (iterator *Iterator) Next() bool {
 		// so return the next entry in current node (if only)
 		if e+1 < len(iterator.node.Entries) {
 
-			iterator.entry = iterator.node.Entries[e+1]
+			iterator.entry = &iterator.node.Entries[e+1]
 
 			goto between
 		}
@@ -87,7 +101,7 @@ func (iterator *Iterator) Next() bool {
 		// Check that there is a next entry position in current node
 		if e < len(iterator.node.Entries) {
 
-			iterator.entry = iterator.node.Entries[e]
+			iterator.entry = &iterator.node.Entries[e]
 
 			goto between
 		}
@@ -106,7 +120,7 @@ between:
 // if Previous() returns true, then previous elements's key and value
 // can be retrieved by Key() and Value()
 // modifies the state of the iterator.
-func (iterator *Iterator) Previous() bool {
+func (iterator *Iterator[K, V]) Previous() bool {
 
 	// If already at beginning, go to begin
 	if iterator.position == begin {
@@ -123,7 +137,7 @@ func (iterator *Iterator) Previous() bool {
 		}
 
 		iterator.node = right
-		iterator.entry = right.Entries[len(right.Entries)-1]
+		iterator.entry = &right.Entries[len(right.Entries)-1]
 
 		goto between
 	}
@@ -140,7 +154,7 @@ func (iterator *Iterator) Previous() bool {
 			}
 
 			// Return the right-most entry
-			iterator.entry = iterator.node.Entries[len(iterator.node.Entries)-1]
+			iterator.entry = &iterator.node.Entries[len(iterator.node.Entries)-1]
 
 			goto between
 		}
@@ -149,7 +163,7 @@ func (iterator *Iterator) Previous() bool {
 		// so return the previous entry in current node (if any)
 		if e-1 >= 0 {
 
-			iterator.entry = iterator.node.Entries[e-1]
+			iterator.entry = &iterator.node.Entries[e-1]
 
 			goto between
 		}
@@ -167,7 +181,7 @@ func (iterator *Iterator) Previous() bool {
 		// Check that there is a previous entry position in current node
 		if e-1 >= 0 {
 
-			iterator.entry = iterator.node.Entries[e-1]
+			iterator.entry = &iterator.node.Entries[e-1]
 
 			goto between
 		}
@@ -183,21 +197,21 @@ between:
 
 // Key returns the current element's key
 // Does not modify the state of the iterator
-func (iterator *Iterator) Key() interface{} {
+func (iterator *Iterator[K, V]) Key() K {
 
 	return iterator.entry.Key
 }
 
 // Value returns the current element's value.
 // Does not modify the state of the iterator
-func (iterator *Iterator) Value() interface{} {
+func (iterator *Iterator[K, V]) Value() V {
 
 	return iterator.entry.Value
 }
 
 // Begin resets the iterator to its initial state (one-before-first)
 // Call Next() to fetch the first element if any.
-func (iterator *Iterator) Begin() {
+func (iterator *Iterator[K, V]) Begin() {
 
 	iterator.node = nil
 	iterator.position = begin
@@ -206,7 +220,7 @@ func (iterator *Iterator) Begin() {
 
 // End moves the iterator past the last element (one-past-the-end)
 // Call Previous() to fetch the last element if any
-func (iterator *Iterator) End() {
+func (iterator *Iterator[K, V]) End() {
 
 	iterator.node = nil
 	iterator.position = end
@@ -217,7 +231,7 @@ func (iterator *Iterator) End() {
 // returns true if there was a first element in the container.
 // If First() return true, then first element's key and value can be retrieved by Key() and Value().
 // Modifies the state of the iterator
-func (iterator *Iterator) First() bool {
+func (iterator *Iterator[K, V]) First() bool {
 
 	iterator.Begin()
 	return iterator.Next()
@@ -226,7 +240,7 @@ func (iterator *Iterator) First() bool {
 // Last moves the iterator to the last element and returns true if there was a last element in the container.
 // If Last() returns true, then last element's key and value can be retrieved by Key() and Value()
 // Modifies the state of the iterator.
-func (iterator *Iterator) Last() bool {
+func (iterator *Iterator[K, V]) Last() bool {
 
 	iterator.End()
 	return iterator.Previous()
@@ -0,0 +1,434 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-24 09:47:15
+ * @Description  : fixed-size pages of a disk-backed B-tree with an LRU page cache
+ */
+package btree
+
+import (
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/Jayj1997/go-common/codec"
+)
+
+// file layout:
+//
+// page 0 is the header
+//
+//	magic "BTPG" | version byte | page size uint32 | order uint32 | root uint64 | size uint64 | pages uint64 | free uint64
+//
+// every other page is a node or a free page, integers are big endian
+//
+//	kind byte | entry count uint16 | children uint64 * (count+1), internal only | entries...
+//
+// each entry is key length uvarint | key bytes | value length uvarint | value bytes,
+// a free page holds the id of the next free page right after its kind.
+const (
+	pagerMagic   = "BTPG"
+	pagerVersion = 1
+
+	headerSize     = len(pagerMagic) + 1 + 4 + 4 + 8 + 8 + 8 + 8
+	nodeHeaderSize = 1 + 2
+
+	// DefaultPageSize is the page size of new files if none is given
+	DefaultPageSize = 4096
+	// DefaultCacheSize is the number of pages kept in memory if none is given
+	DefaultCacheSize = 256
+)
+
+const (
+	pageLeaf byte = iota + 1
+	pageInternal
+	pageFree
+)
+
+var (
+	// ErrPageOverflow is returned when a node doesn't fit into a page
+	ErrPageOverflow = errors.New("btree: node overflows page")
+	// ErrEntryTooLarge is returned by DiskTree.Insert when an encoded entry may not fit into a page
+	ErrEntryTooLarge = errors.New("btree: entry too large for page")
+	// ErrClosed is returned when using a DiskTree after Close
+	ErrClosed = errors.New("btree: disk tree is closed")
+)
+
+// page is a decoded node, children are page ids, 0 (the header page) stands for none
+type page[K, V any] struct {
+	id       uint64
+	entries  []Entry[K, V]
+	children []uint64
+	dirty    bool
+}
+
+func (p *page[K, V]) leaf() bool {
+	return len(p.children) == 0
+}
+
+// meta is the content of the header page
+type meta struct {
+	pageSize int
+	order    int
+	root     uint64
+	size     int
+	pages    uint64 // number of pages in the file, including the header
+	free     uint64 // head of the free page list
+}
+
+// pager reads and writes pages of a file, decoded pages are kept in an LRU cache.
+// Pages are handed out as pointers that stay valid until shrink, which evicts
+// the least recently used pages beyond capacity, writing the dirty ones back.
+type pager[K, V any] struct {
+	file     *os.File
+	meta     meta
+	keys     codec.Codec[K]
+	values   codec.Codec[V]
+	capacity int
+	cache    map[uint64]*list.Element
+	lru      *list.List // front is the most recently used
+}
+
+// openPager opens or creates the file at path, a new file is initialized by m
+func openPager[K, V any](path string, m meta, capacity int, keys codec.Codec[K], values codec.Codec[V]) (*pager[K, V], error) {
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pager[K, V]{
+		file:     file,
+		keys:     keys,
+		values:   values,
+		capacity: capacity,
+		cache:    make(map[uint64]*list.Element),
+		lru:      list.New(),
+	}
+
+	info, err := file.Stat()
+	if err == nil {
+		if info.Size() == 0 {
+			m.pages = 1
+			p.meta = m
+			err = p.writeMeta()
+		} else {
+			err = p.readMeta()
+		}
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *pager[K, V]) readMeta() error {
+
+	buffer := make([]byte, headerSize)
+	if _, err := p.file.ReadAt(buffer, 0); err != nil {
+		return fmt.Errorf("%w: read header: %v", ErrFormat, err)
+	}
+
+	if string(buffer[:len(pagerMagic)]) != pagerMagic {
+		return fmt.Errorf("%w: bad magic %q", ErrFormat, buffer[:len(pagerMagic)])
+	}
+
+	if version := buffer[len(pagerMagic)]; version != pagerVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrFormat, version)
+	}
+
+	buffer = buffer[len(pagerMagic)+1:]
+
+	m := meta{
+		pageSize: int(binary.BigEndian.Uint32(buffer)),
+		order:    int(binary.BigEndian.Uint32(buffer[4:])),
+		root:     binary.BigEndian.Uint64(buffer[8:]),
+		size:     int(binary.BigEndian.Uint64(buffer[16:])),
+		pages:    binary.BigEndian.Uint64(buffer[24:]),
+		free:     binary.BigEndian.Uint64(buffer[32:]),
+	}
+
+	// the buffers of fetch and decode are sized by the header, so it is checked before any page is read
+	if m.pageSize < headerSize {
+		return fmt.Errorf("%w: page size %d smaller than the header", ErrFormat, m.pageSize)
+	}
+
+	if m.order < 3 || m.order > math.MaxUint16 || maxEntry(m.pageSize, m.order) < 1 {
+		return fmt.Errorf("%w: order %d doesn't fit a page of %d bytes", ErrFormat, m.order, m.pageSize)
+	}
+
+	p.meta = m
+
+	return nil
+}
+
+func (p *pager[K, V]) writeMeta() error {
+
+	buffer := make([]byte, 0, p.meta.pageSize)
+	buffer = append(buffer, pagerMagic...)
+	buffer = append(buffer, pagerVersion)
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(p.meta.pageSize))
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(p.meta.order))
+	buffer = binary.BigEndian.AppendUint64(buffer, p.meta.root)
+	buffer = binary.BigEndian.AppendUint64(buffer, uint64(p.meta.size))
+	buffer = binary.BigEndian.AppendUint64(buffer, p.meta.pages)
+	buffer = binary.BigEndian.AppendUint64(buffer, p.meta.free)
+
+	_, err := p.file.WriteAt(buffer[:p.meta.pageSize], 0)
+
+	return err
+}
+
+// fetch returns the page of id, reading it from the file on cache miss
+func (p *pager[K, V]) fetch(id uint64) (*page[K, V], error) {
+
+	if element, ok := p.cache[id]; ok {
+		p.lru.MoveToFront(element)
+		return element.Value.(*page[K, V]), nil
+	}
+
+	if id == 0 || id >= p.meta.pages {
+		return nil, fmt.Errorf("%w: page %d out of range", ErrFormat, id)
+	}
+
+	buffer := make([]byte, p.meta.pageSize)
+	if _, err := p.file.ReadAt(buffer, p.offset(id)); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	node, err := p.decode(id, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache[id] = p.lru.PushFront(node)
+
+	return node, nil
+}
+
+// allocate returns a new dirty page, reusing free pages first
+func (p *pager[K, V]) allocate() (*page[K, V], error) {
+
+	id := p.meta.free
+
+	if id != 0 {
+		buffer := make([]byte, 1+8)
+		if _, err := p.file.ReadAt(buffer, p.offset(id)); err != nil {
+			return nil, err
+		}
+
+		if buffer[0] != pageFree {
+			return nil, fmt.Errorf("%w: page %d on free list is in use", ErrFormat, id)
+		}
+
+		p.meta.free = binary.BigEndian.Uint64(buffer[1:])
+	} else {
+		id = p.meta.pages
+		p.meta.pages++
+	}
+
+	node := &page[K, V]{id: id, dirty: true}
+	p.cache[id] = p.lru.PushFront(node)
+
+	return node, nil
+}
+
+// release drops the page from the cache and puts it on the free list
+func (p *pager[K, V]) release(node *page[K, V]) error {
+
+	if element, ok := p.cache[node.id]; ok {
+		p.lru.Remove(element)
+		delete(p.cache, node.id)
+	}
+
+	buffer := make([]byte, 1, 1+8)
+	buffer[0] = pageFree
+	buffer = binary.BigEndian.AppendUint64(buffer, p.meta.free)
+
+	if _, err := p.file.WriteAt(buffer, p.offset(node.id)); err != nil {
+		return err
+	}
+
+	p.meta.free = node.id
+
+	return nil
+}
+
+// shrink evicts the least recently used pages until the cache is within capacity
+func (p *pager[K, V]) shrink() error {
+
+	for p.lru.Len() > p.capacity {
+
+		element := p.lru.Back()
+		node := element.Value.(*page[K, V])
+
+		if err := p.write(node); err != nil {
+			return err
+		}
+
+		p.lru.Remove(element)
+		delete(p.cache, node.id)
+	}
+
+	return nil
+}
+
+// flush writes back all dirty pages and the header
+func (p *pager[K, V]) flush() error {
+
+	for element := p.lru.Front(); element != nil; element = element.Next() {
+		if err := p.write(element.Value.(*page[K, V])); err != nil {
+			return err
+		}
+	}
+
+	return p.writeMeta()
+}
+
+func (p *pager[K, V]) write(node *page[K, V]) error {
+
+	if !node.dirty {
+		return nil
+	}
+
+	buffer, err := p.encode(node)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.file.WriteAt(buffer, p.offset(node.id)); err != nil {
+		return err
+	}
+
+	node.dirty = false
+
+	return nil
+}
+
+func (p *pager[K, V]) offset(id uint64) int64 {
+	return int64(id) * int64(p.meta.pageSize)
+}
+
+func (p *pager[K, V]) encode(node *page[K, V]) ([]byte, error) {
+
+	buffer := make([]byte, 0, p.meta.pageSize)
+
+	if node.leaf() {
+		buffer = append(buffer, pageLeaf)
+	} else {
+		buffer = append(buffer, pageInternal)
+	}
+
+	buffer = binary.BigEndian.AppendUint16(buffer, uint16(len(node.entries)))
+
+	for _, child := range node.children {
+		buffer = binary.BigEndian.AppendUint64(buffer, child)
+	}
+
+	for _, entry := range node.entries {
+
+		var err error
+		if buffer, err = p.appendEntry(buffer, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(buffer) > p.meta.pageSize {
+		return nil, fmt.Errorf("%w: page %d takes %d bytes", ErrPageOverflow, node.id, len(buffer))
+	}
+
+	return buffer[:p.meta.pageSize], nil
+}
+
+func (p *pager[K, V]) appendEntry(buffer []byte, entry Entry[K, V]) ([]byte, error) {
+
+	key, err := p.keys.Encode(entry.Key)
+	if err != nil {
+		return nil, fmt.Errorf("btree: encode key %v: %w", entry.Key, err)
+	}
+
+	value, err := p.values.Encode(entry.Value)
+	if err != nil {
+		return nil, fmt.Errorf("btree: encode value of key %v: %w", entry.Key, err)
+	}
+
+	buffer = binary.AppendUvarint(buffer, uint64(len(key)))
+	buffer = append(buffer, key...)
+	buffer = binary.AppendUvarint(buffer, uint64(len(value)))
+	buffer = append(buffer, value...)
+
+	return buffer, nil
+}
+
+func (p *pager[K, V]) decode(id uint64, buffer []byte) (*page[K, V], error) {
+
+	kind := buffer[0]
+	if kind != pageLeaf && kind != pageInternal {
+		return nil, fmt.Errorf("%w: page %d is not a node", ErrFormat, id)
+	}
+
+	count := int(binary.BigEndian.Uint16(buffer[1:]))
+	buffer = buffer[nodeHeaderSize:]
+
+	node := &page[K, V]{id: id, entries: make([]Entry[K, V], 0, count)}
+
+	if kind == pageInternal {
+
+		if len(buffer) < (count+1)*8 {
+			return nil, fmt.Errorf("%w: page %d has too many children", ErrFormat, id)
+		}
+
+		node.children = make([]uint64, count+1)
+		for i := range node.children {
+			node.children[i] = binary.BigEndian.Uint64(buffer[i*8:])
+		}
+
+		buffer = buffer[(count+1)*8:]
+	}
+
+	for i := 0; i < count; i++ {
+
+		key, rest, err := readBytes(buffer)
+		if err != nil {
+			return nil, fmt.Errorf("%w: page %d: %v", ErrFormat, id, err)
+		}
+
+		value, rest, err := readBytes(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: page %d: %v", ErrFormat, id, err)
+		}
+
+		buffer = rest
+
+		entry := Entry[K, V]{}
+
+		if entry.Key, err = p.keys.Decode(key); err != nil {
+			return nil, fmt.Errorf("btree: decode key in page %d: %w", id, err)
+		}
+
+		if entry.Value, err = p.values.Decode(value); err != nil {
+			return nil, fmt.Errorf("btree: decode value of key %v: %w", entry.Key, err)
+		}
+
+		node.entries = append(node.entries, entry)
+	}
+
+	return node, nil
+}
+
+// readBytes reads a uvarint length prefixed chunk
+func readBytes(buffer []byte) (chunk, rest []byte, err error) {
+
+	length, n := binary.Uvarint(buffer)
+	if n <= 0 || uint64(len(buffer)-n) < length {
+		return nil, nil, errors.New("truncated entry")
+	}
+
+	return buffer[n : n+int(length)], buffer[n+int(length):], nil
+}
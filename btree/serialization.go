@@ -6,39 +6,359 @@
 package btree
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 
-	"github.com/Jayj1997/go-common"
+	"github.com/Jayj1997/go-common/codec"
 )
 
+// JSON format is an array of {"key": ..., "value": ...} objects in ascending key order,
+// so keys keep their JSON type instead of being turned into object member names.
+
+// entry is the JSON representation of a single key-value pair
+type entry[K, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
 // ToJSON outputs the JSON representation of the tree
-func (tree *Tree) ToJSON() ([]byte, error) {
+func (tree *Tree[K, V]) ToJSON() ([]byte, error) {
 
-	elements := make(map[string]interface{})
+	elements := make([]entry[K, V], 0, tree.size)
 	it := tree.Iterator()
 
 	for it.Next() {
-		elements[common.ToString(it.Key())] = it.Value()
+		elements = append(elements, entry[K, V]{Key: it.Key(), Value: it.Value()})
 	}
 
-	return json.Marshal(&elements)
+	return json.Marshal(elements)
 }
 
-// FromJSON populates the tree from the input JSON representation
-func (tree *Tree) FromJSON(data []byte) error {
+// FromJSON populates the tree from the input JSON representation.
+// Keys are decoded by tree.KeyDecoder if set, otherwise into K by encoding/json,
+// a key the comparator can't handle, e.g. a number decoded as float64 into an interface{} key
+// of a tree of comparator.IntComparator without KeyDecoder, is reported as an error.
+// On error the tree is left untouched.
+func (tree *Tree[K, V]) FromJSON(data []byte) error {
+
+	var elements []entry[json.RawMessage, V]
 
-	elements := make(map[string]interface{})
-	err := json.Unmarshal(data, &elements)
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
 
-	if err == nil {
+	keys := make([]K, len(elements))
 
-		tree.Clear()
+	for i, element := range elements {
 
-		for key, value := range elements {
+		key, err := tree.decodeKey(element.Key)
+		if err != nil {
+			return fmt.Errorf("btree: decode key %s: %w", element.Key, err)
+		}
 
-			tree.Insert(key, value)
+		if err := tree.CheckKey(key); err != nil {
+			return fmt.Errorf("btree: decode key %s: %w", element.Key, err)
 		}
+
+		keys[i] = key
+	}
+
+	tree.Clear()
+
+	for i, element := range elements {
+		tree.Insert(keys[i], element.Value)
+	}
+
+	return nil
+}
+
+func (tree *Tree[K, V]) decodeKey(data []byte) (key K, err error) {
+
+	if tree.KeyDecoder != nil {
+		return tree.KeyDecoder(data)
+	}
+
+	err = json.Unmarshal(data, &key)
+
+	return key, err
+}
+
+// KeyDecoderOf returns a key decoder for trees over interface{} keys which decodes keys into T,
+// it should match the comparator's type assertion,
+// e.g. KeyDecoderOf[int64]() for a tree of comparator.Int64Comparator.
+func KeyDecoderOf[T any]() func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var key T
+		err := json.Unmarshal(data, &key)
+		return key, err
 	}
+}
+
+// AutoKeyDecoder returns a key decoder for trees of comparator.Auto,
+// numbers are decoded into json.Number so large integers are not rounded to float64.
+func AutoKeyDecoder() func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var key interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err := decoder.Decode(&key)
+		return key, err
+	}
+}
+
+// binary format:
+//
+//	magic "BTRE" | version byte | order uvarint | size uvarint | entries...
+//
+// each entry is written in ascending key order as
+//
+//	key length uvarint | key bytes | value length uvarint | value bytes
+const (
+	binaryMagic   = "BTRE"
+	binaryVersion = 1
+)
+
+// ErrFormat is returned when binary data is not a serialized B-tree of a supported version
+var ErrFormat = errors.New("btree: invalid binary format")
+
+// MarshalBinary implements encoding.BinaryMarshaler, keys and values are encoded by
+// tree.KeyCodec and tree.ValueCodec (gob if nil) so their types are kept exactly.
+// It also makes the tree encodable by encoding/gob.
+func (tree *Tree[K, V]) MarshalBinary() ([]byte, error) {
+
+	var buffer bytes.Buffer
+	_, err := tree.WriteTo(&buffer)
+
+	return buffer.Bytes(), err
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, it replaces the content of the tree,
+// which should be instantiated with the comparator (and codecs) it was marshaled with.
+// A tree without Comparator, e.g. allocated by encoding/gob, can't be unmarshaled into and returns an error,
+// so a gob-decoded struct should hold a tree already instantiated by New or NewWithFunc.
+func (tree *Tree[K, V]) UnmarshalBinary(data []byte) error {
+
+	_, err := tree.ReadFrom(bytes.NewReader(data))
 
 	return err
 }
+
+// WriteTo implements io.WriterTo, it streams the binary representation of the tree into w
+func (tree *Tree[K, V]) WriteTo(w io.Writer) (int64, error) {
+
+	keys, values := tree.codecs()
+	writer := &countWriter{writer: bufio.NewWriter(w)}
+
+	writer.Write([]byte(binaryMagic))
+	writer.Write([]byte{binaryVersion})
+	writer.Write(binary.AppendUvarint(nil, uint64(tree.m)))
+	writer.Write(binary.AppendUvarint(nil, uint64(tree.size)))
+
+	it := tree.Iterator()
+
+	for it.Next() && writer.err == nil {
+
+		key, err := keys.Encode(it.Key())
+		if err != nil {
+			return writer.count, fmt.Errorf("btree: encode key %v: %w", it.Key(), err)
+		}
+
+		value, err := values.Encode(it.Value())
+		if err != nil {
+			return writer.count, fmt.Errorf("btree: encode value of key %v: %w", it.Key(), err)
+		}
+
+		writer.Write(binary.AppendUvarint(nil, uint64(len(key))))
+		writer.Write(key)
+		writer.Write(binary.AppendUvarint(nil, uint64(len(value))))
+		writer.Write(value)
+	}
+
+	if writer.err == nil {
+		writer.err = writer.writer.Flush()
+	}
+
+	return writer.count, writer.err
+}
+
+// ReadFrom implements io.ReaderFrom, it replaces the content of the tree by the binary representation read from r.
+// Entries are bulk loaded, so ErrUnsorted is returned if they are out of order for tree.Comparator.
+// On error the tree is left untouched.
+//
+// r is not read past the end of the tree, so more data may follow it on the same stream.
+// Lengths are read byte by byte, r should implement io.ByteReader (e.g. *bufio.Reader) to keep it fast.
+func (tree *Tree[K, V]) ReadFrom(r io.Reader) (int64, error) {
+
+	if tree.Comparator == nil {
+		return 0, errors.New("btree: ReadFrom into a tree without Comparator, instantiate it with New or NewWithFunc")
+	}
+
+	keys, values := tree.codecs()
+	reader := &countReader{reader: r}
+	if byteReader, ok := r.(io.ByteReader); ok {
+		reader.byteReader = byteReader
+	}
+
+	header := make([]byte, len(binaryMagic)+1)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return reader.count, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return reader.count, fmt.Errorf("%w: bad magic %q", ErrFormat, header[:len(binaryMagic)])
+	}
+
+	if version := header[len(binaryMagic)]; version != binaryVersion {
+		return reader.count, fmt.Errorf("%w: unsupported version %d", ErrFormat, version)
+	}
+
+	order, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return reader.count, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return reader.count, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	// a tree without order takes the one it was written with
+	loaded := &Tree[K, V]{Comparator: tree.Comparator, m: tree.m}
+	if loaded.m == 0 {
+		if order < 3 {
+			return reader.count, fmt.Errorf("%w: invalid order %d", ErrFormat, order)
+		}
+
+		loaded.m = int(order)
+	}
+
+	entries := make([]Entry[K, V], 0, min(size, 1<<16))
+
+	for i := uint64(0); i < size; i++ {
+
+		keyBytes, err := readChunk(reader)
+		if err != nil {
+			return reader.count, err
+		}
+
+		valueBytes, err := readChunk(reader)
+		if err != nil {
+			return reader.count, err
+		}
+
+		key, err := keys.Decode(keyBytes)
+		if err != nil {
+			return reader.count, fmt.Errorf("btree: decode key of entry %d: %w", i, err)
+		}
+
+		value, err := values.Decode(valueBytes)
+		if err != nil {
+			return reader.count, fmt.Errorf("btree: decode value of key %v: %w", key, err)
+		}
+
+		entries = append(entries, Entry[K, V]{Key: key, Value: value})
+	}
+
+	if err := loaded.BulkLoad(entries, 1); err != nil {
+		return reader.count, err
+	}
+
+	tree.Root, tree.size, tree.m = loaded.Root, loaded.size, loaded.m
+
+	return reader.count, nil
+}
+
+// codecs returns the codecs of binary serialization, gob by default
+func (tree *Tree[K, V]) codecs() (codec.Codec[K], codec.Codec[V]) {
+
+	keys, values := tree.KeyCodec, tree.ValueCodec
+
+	if keys == nil {
+		keys = codec.Gob[K]()
+	}
+
+	if values == nil {
+		values = codec.Gob[V]()
+	}
+
+	return keys, values
+}
+
+func readChunk(reader *countReader) ([]byte, error) {
+
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	if length > maxChunk {
+		return nil, fmt.Errorf("%w: chunk of %d bytes", ErrFormat, length)
+	}
+
+	chunk := make([]byte, length)
+	if _, err := io.ReadFull(reader, chunk); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	return chunk, nil
+}
+
+// maxChunk bounds the length of a single encoded key or value, so corrupted lengths don't exhaust memory
+const maxChunk = 1 << 30
+
+// countWriter counts written bytes and keeps the first error, later writes are skipped
+type countWriter struct {
+	writer *bufio.Writer
+	count  int64
+	err    error
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	w.err = err
+
+	return n, err
+}
+
+// countReader counts consumed bytes, bytes are read one by one from reader unless it is an io.ByteReader
+type countReader struct {
+	reader     io.Reader
+	byteReader io.ByteReader
+	count      int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+
+	return n, err
+}
+
+func (r *countReader) ReadByte() (byte, error) {
+
+	if r.byteReader != nil {
+		b, err := r.byteReader.ReadByte()
+		if err == nil {
+			r.count++
+		}
+
+		return b, err
+	}
+
+	var b [1]byte
+	_, err := io.ReadFull(r, b[:])
+
+	return b[0], err
+}
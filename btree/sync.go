@@ -0,0 +1,293 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-17 15:12:36
+ * @Description  : concurrency-safe B-tree
+ */
+package btree
+
+import (
+	"cmp"
+	"sync"
+
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+// SyncTree is a B-tree safe for concurrent use by multiple goroutines,
+// all methods are guarded by a sync.RWMutex.
+//
+// Iterator works on a copy of the tree taken under the read lock,
+// so iterating never holds the lock while writers keep going.
+type SyncTree[K, V any] struct {
+	mu   sync.RWMutex
+	tree *Tree[K, V]
+}
+
+// NewSync instantiates a concurrency-safe B-tree with order(maximum number of children) over an ordered key type
+func NewSync[K cmp.Ordered, V any](order int) *SyncTree[K, V] {
+	return &SyncTree[K, V]{tree: New[K, V](order)}
+}
+
+// NewSyncWithFunc instantiates a concurrency-safe B-tree with order(maximum number of children) and typed key compare function
+func NewSyncWithFunc[K, V any](order int, compare func(a, b K) int) *SyncTree[K, V] {
+	return &SyncTree[K, V]{tree: NewWithFunc[K, V](order, compare)}
+}
+
+// NewSyncWith instantiates a concurrency-safe B-tree with order(maximum number of children) and costom key comparator,
+// i.e. keys and values are of type interface{}.
+func NewSyncWith(order int, comparator comparator.Comparator) *SyncTree[interface{}, interface{}] {
+	return &SyncTree[interface{}, interface{}]{tree: NewWith(order, comparator)}
+}
+
+// Insert inserts key-value pair node into the tree, see Tree.Insert
+func (tree *SyncTree[K, V]) Insert(key K, value V) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	tree.tree.Insert(key, value)
+}
+
+// Get searches the node in the tree by key, see Tree.Get
+func (tree *SyncTree[K, V]) Get(key K) (value V, found bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Get(key)
+}
+
+// Remove remove the node from the tree by key, see Tree.Remove
+func (tree *SyncTree[K, V]) Remove(key K) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	tree.tree.Remove(key)
+}
+
+// CheckKey returns the key type error of key, see Tree.CheckKey
+func (tree *SyncTree[K, V]) CheckKey(key K) error {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.CheckKey(key)
+}
+
+// TryInsert inserts key-value pair into the tree or returns the key type error, see Tree.TryInsert
+func (tree *SyncTree[K, V]) TryInsert(key K, value V) error {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	return tree.tree.TryInsert(key, value)
+}
+
+// TryGet searches the node in the tree by key or returns the key type error, see Tree.TryGet
+func (tree *SyncTree[K, V]) TryGet(key K) (value V, found bool, err error) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.TryGet(key)
+}
+
+// TryRemove removes the node from the tree by key or returns the key type error, see Tree.TryRemove
+func (tree *SyncTree[K, V]) TryRemove(key K) error {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	return tree.tree.TryRemove(key)
+}
+
+// GetOrInsert returns the existing value for the key if present,
+// otherwise it inserts and returns the given value.
+// Second return parameter is true if the value was loaded, false if inserted.
+func (tree *SyncTree[K, V]) GetOrInsert(key K, value V) (actual V, loaded bool) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if actual, loaded = tree.tree.Get(key); loaded {
+		return actual, true
+	}
+
+	tree.tree.Insert(key, value)
+
+	return value, false
+}
+
+// Update atomically replaces the value of key by the result of fn,
+// fn receives the current value and whether key was found,
+// the key is inserted (or overwritten) when fn returns keep as true, otherwise it is removed.
+// fn runs under the write lock so it must not call back into the tree.
+func (tree *SyncTree[K, V]) Update(key K, fn func(old V, found bool) (value V, keep bool)) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	value, keep := fn(tree.tree.Get(key))
+	if keep {
+		tree.tree.Insert(key, value)
+		return
+	}
+
+	tree.tree.Remove(key)
+}
+
+// CompareAndSwap swaps the value of key to new if the current value is equal to old,
+// returns true if the value was swapped. Use SyncTree.CompareAndSwapFunc for values which aren't comparable.
+func CompareAndSwap[K any, V comparable](tree *SyncTree[K, V], key K, old, new V) (swapped bool) {
+	return tree.CompareAndSwapFunc(key, old, new, func(a, b V) bool {
+		return a == b
+	})
+}
+
+// CompareAndSwapFunc swaps the value of key to new if equal reports the current value equal to old,
+// returns true if the value was swapped.
+// equal runs under the write lock so it must not call back into the tree.
+func (tree *SyncTree[K, V]) CompareAndSwapFunc(key K, old, new V, equal func(a, b V) bool) (swapped bool) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	current, found := tree.tree.Get(key)
+	if !found || !equal(current, old) {
+		return false
+	}
+
+	tree.tree.Insert(key, new)
+
+	return true
+}
+
+// Empty returns true if tree does not contain any nodes
+func (tree *SyncTree[K, V]) Empty() bool {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Empty()
+}
+
+// Size returns number of nodes in the tree
+func (tree *SyncTree[K, V]) Size() int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Size()
+}
+
+// Keys returns all keys in-order
+func (tree *SyncTree[K, V]) Keys() []K {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Keys()
+}
+
+// Values returns all values in-order based on the key.
+func (tree *SyncTree[K, V]) Values() []V {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Values()
+}
+
+// Height returns the height of the tree
+func (tree *SyncTree[K, V]) Height() int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Height()
+}
+
+// LeftKey returns the left-most(min) key or nil if tree was empty
+func (tree *SyncTree[K, V]) LeftKey() K {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.LeftKey()
+}
+
+// LeftValue returns the left-most(min) value or nil if tree was empty
+func (tree *SyncTree[K, V]) LeftValue() V {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.LeftValue()
+}
+
+// RightKey returns the right-most(max) key or nil if tree is empty
+func (tree *SyncTree[K, V]) RightKey() K {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.RightKey()
+}
+
+// RightValue returns the right-most(max) value or nil if tree was empty
+func (tree *SyncTree[K, V]) RightValue() V {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.RightValue()
+}
+
+// Floor returns the largest key smaller than or equal to the given key, see Tree.Floor
+func (tree *SyncTree[K, V]) Floor(key K) (K, V, bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Floor(key)
+}
+
+// Lower returns the largest key strictly smaller than the given key, see Tree.Lower
+func (tree *SyncTree[K, V]) Lower(key K) (K, V, bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Lower(key)
+}
+
+// Ceiling returns the smallest key larger than or equal to the given key, see Tree.Ceiling
+func (tree *SyncTree[K, V]) Ceiling(key K) (K, V, bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Ceiling(key)
+}
+
+// Higher returns the smallest key strictly larger than the given key, see Tree.Higher
+func (tree *SyncTree[K, V]) Higher(key K) (K, V, bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Higher(key)
+}
+
+// Clear removes all nodes from the tree
+func (tree *SyncTree[K, V]) Clear() {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	tree.tree.Clear()
+}
+
+// Snapshot returns a copy of the tree taken under the read lock in O(n),
+// the copy is not affected by later changes of the tree.
+func (tree *SyncTree[K, V]) Snapshot() *Tree[K, V] {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.clone()
+}
+
+// Iterator returns a stateful iterator over a snapshot of the tree,
+// the lock is only held while taking the snapshot.
+func (tree *SyncTree[K, V]) Iterator() Iterator[K, V] {
+	return tree.Snapshot().Iterator()
+}
+
+// IteratorFrom returns a stateful iterator over a snapshot of the tree positioned right before the ceiling of key
+func (tree *SyncTree[K, V]) IteratorFrom(key K) Iterator[K, V] {
+	return tree.Snapshot().IteratorFrom(key)
+}
+
+// String returns a string representation of container (for debugging purposes)
+func (tree *SyncTree[K, V]) String() string {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.String()
+}
@@ -0,0 +1,117 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-28 11:05:12
+ * @Description  : structural invariants of the B-tree
+ */
+package btree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalid is wrapped by the errors returned from Validate
+var ErrInvalid = errors.New("btree: invalid tree")
+
+// Validate checks the B-tree properties and returns an error describing the first violation:
+// every node holds at most order-1 entries and all but the root at least ceil(order/2)-1,
+// internal nodes have one child more than entries, all leaves are at the same depth,
+// keys are in strictly ascending order by the comparator, every child points back to its parent
+// and the number of entries matches Size().
+// It takes O(n) and is meant for tests and debugging.
+func (tree *Tree[K, V]) Validate() error {
+
+	if tree.Root == nil {
+		if tree.size != 0 {
+			return fmt.Errorf("%w: empty tree has size %d", ErrInvalid, tree.size)
+		}
+
+		return nil
+	}
+
+	if tree.Root.Parent != nil {
+		return fmt.Errorf("%w: root has a parent", ErrInvalid)
+	}
+
+	v := validator[K, V]{tree: tree, leafDepth: -1}
+
+	if err := v.validate(tree.Root, 0); err != nil {
+		return err
+	}
+
+	if v.count != tree.size {
+		return fmt.Errorf("%w: %d entries, size is %d", ErrInvalid, v.count, tree.size)
+	}
+
+	return nil
+}
+
+// validator holds the state of an in-order walk
+type validator[K, V any] struct {
+	tree      *Tree[K, V]
+	leafDepth int
+	count     int
+	previous  *Entry[K, V]
+}
+
+func (v *validator[K, V]) validate(node *Node[K, V], depth int) error {
+
+	tree := v.tree
+
+	if len(node.Entries) > tree.maxEntries() {
+		return fmt.Errorf("%w: node %v has more than %d entries", ErrInvalid, node.Entries, tree.maxEntries())
+	}
+
+	if len(node.Entries) < tree.minEntries() && node != tree.Root || len(node.Entries) == 0 {
+		return fmt.Errorf("%w: node %v at depth %d has too few entries", ErrInvalid, node.Entries, depth)
+	}
+
+	if tree.isLeaf(node) {
+		if v.leafDepth < 0 {
+			v.leafDepth = depth
+		} else if v.leafDepth != depth {
+			return fmt.Errorf("%w: leaves at depth %d and %d", ErrInvalid, v.leafDepth, depth)
+		}
+	} else if len(node.Children) != len(node.Entries)+1 {
+		return fmt.Errorf("%w: node %v has %d children", ErrInvalid, node.Entries, len(node.Children))
+	}
+
+	for i := range node.Entries {
+
+		if !tree.isLeaf(node) {
+			if err := v.child(node, i, depth); err != nil {
+				return err
+			}
+		}
+
+		entry := &node.Entries[i]
+
+		if v.previous != nil && tree.Comparator(v.previous.Key, entry.Key) >= 0 {
+			return fmt.Errorf("%w: key %v is not smaller than %v", ErrInvalid, v.previous.Key, entry.Key)
+		}
+
+		v.previous = entry
+		v.count++
+	}
+
+	if !tree.isLeaf(node) {
+		return v.child(node, len(node.Entries), depth)
+	}
+
+	return nil
+}
+
+func (v *validator[K, V]) child(node *Node[K, V], index int, depth int) error {
+
+	child := node.Children[index]
+
+	if child == nil {
+		return fmt.Errorf("%w: node %v has nil child %d", ErrInvalid, node.Entries, index)
+	}
+
+	if child.Parent != node {
+		return fmt.Errorf("%w: parent of %v is not %v", ErrInvalid, child.Entries, node.Entries)
+	}
+
+	return v.validate(child, depth+1)
+}
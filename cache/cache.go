@@ -0,0 +1,403 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-18 10:05:43
+ * @Description  : in-process cache with LRU, LFU and TTL eviction
+ */
+package cache
+
+import (
+	"cmp"
+	"container/list"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Jayj1997/go-common/rbt"
+)
+
+// Policy decides which entry is evicted when the cache is over its limits
+type Policy byte
+
+const (
+	// LRU evicts the least recently used entry
+	LRU Policy = iota
+	// LFU evicts the least frequently used entry, the least recently used one among equals
+	LFU
+	// TTL evicts the entry closest to expiring, entries without expiration go last in insertion order
+	TTL
+)
+
+// never is the deadline of an entry without expiration
+const never = math.MaxInt64
+
+// Cache is an in-process key-value cache bounded by the number of entries and their total size,
+// entries may expire after a time to live. Cache is safe for concurrent use.
+//
+// Expired entries are never returned, they are dropped when read and before every write.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	options Options
+	sizer   func(value V) int64 // see NewWithSizer
+	entries map[K]*item[K, V]
+	size    int64
+	tick    uint64 // increases on every access, orders entries of same frequency or deadline
+
+	recency   *list.List                   // LRU, most recently used first
+	frequency *rbt.Tree[rank, *item[K, V]] // LFU, least frequently used first
+	deadlines *rbt.Tree[rank, *item[K, V]] // entries which expire, and all entries under TTL policy
+	loads     map[K]*call[V]               // loads in flight, see GetOrLoad
+	stats     Stats
+}
+
+// item is a cached key-value pair along with its position in the indexes
+type item[K comparable, V any] struct {
+	key       K
+	value     V
+	size      int64
+	element   *list.Element
+	frequency rank
+	deadline  rank
+}
+
+// rank orders entries by a priority, i.e. frequency or deadline, then by tick
+type rank struct {
+	priority int64
+	tick     uint64
+}
+
+func compareRank(a, b rank) int {
+	if diff := cmp.Compare(a.priority, b.priority); diff != 0 {
+		return diff
+	}
+
+	return cmp.Compare(a.tick, b.tick)
+}
+
+// Options configures a Cache
+type Options struct {
+	// eviction policy, LRU by default
+	Policy Policy
+	// maximum number of entries, unlimited if 0
+	MaxEntries int
+	// maximum total size of entries as told by the sizer of NewWithSizer, unlimited if 0
+	MaxSize int64
+	// time to live of entries set by Set, never expire if 0
+	TTL time.Duration
+	// current time, time.Now by default
+	Clock func() time.Time
+}
+
+// Option sets an option of Cache
+type Option func(*Options)
+
+// WithPolicy sets the eviction policy
+func WithPolicy(policy Policy) Option {
+	return func(options *Options) {
+		options.Policy = policy
+	}
+}
+
+// WithMaxEntries limits the number of entries
+func WithMaxEntries(max int) Option {
+	return func(options *Options) {
+		options.MaxEntries = max
+	}
+}
+
+// WithMaxSize limits the total size of entries, see NewWithSizer
+func WithMaxSize(max int64) Option {
+	return func(options *Options) {
+		options.MaxSize = max
+	}
+}
+
+// WithTTL makes entries expire ttl after they are set
+func WithTTL(ttl time.Duration) Option {
+	return func(options *Options) {
+		options.TTL = ttl
+	}
+}
+
+// WithClock replaces time.Now, e.g. by a fake clock in tests
+func WithClock(clock func() time.Time) Option {
+	return func(options *Options) {
+		options.Clock = clock
+	}
+}
+
+// New instantiates a cache, an LRU cache without limits by default
+func New[K comparable, V any](options ...Option) *Cache[K, V] {
+	return NewWithSizer[K, V](nil, options...)
+}
+
+// NewWithSizer instantiates a cache where sizer tells the size of a value counted against MaxSize,
+// every entry is of size 1 if sizer is nil.
+func NewWithSizer[K comparable, V any](sizer func(value V) int64, options ...Option) *Cache[K, V] {
+
+	cache := &Cache[K, V]{
+		options:   Options{Policy: LRU, Clock: time.Now},
+		sizer:     sizer,
+		entries:   make(map[K]*item[K, V]),
+		recency:   list.New(),
+		frequency: rbt.NewWithFunc[rank, *item[K, V]](compareRank),
+		deadlines: rbt.NewWithFunc[rank, *item[K, V]](compareRank),
+		loads:     make(map[K]*call[V]),
+	}
+
+	for _, option := range options {
+		option(&cache.options)
+	}
+
+	return cache
+}
+
+// Get returns the value of key, second return parameter is false if key is not cached or expired
+func (cache *Cache[K, V]) Get(key K) (value V, found bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.get(key)
+}
+
+// Set caches value for key with the TTL of the cache, evicting entries if the cache is over its limits
+func (cache *Cache[K, V]) Set(key K, value V) {
+	cache.SetWithTTL(key, value, cache.options.TTL)
+}
+
+// SetWithTTL caches value for key expiring after ttl, never if ttl is 0,
+// evicting entries if the cache is over its limits.
+// An entry larger than MaxSize is not cached and evicts nothing, the former value of key is removed though.
+func (cache *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.invalidate(key)
+	cache.set(key, value, ttl)
+}
+
+// Remove removes key from the cache, returns true if it was cached
+func (cache *Cache[K, V]) Remove(key K) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.invalidate(key)
+
+	entry, found := cache.entries[key]
+	if found {
+		cache.remove(entry)
+	}
+
+	return found
+}
+
+// RemoveExpired removes the expired entries and returns their number,
+// it needn't be called for correctness but frees the memory of entries nobody reads anymore.
+func (cache *Cache[K, V]) RemoveExpired() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.expire()
+}
+
+// Len returns the number of entries, including expired ones not removed yet
+func (cache *Cache[K, V]) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return len(cache.entries)
+}
+
+// Size returns the total size of entries, see NewWithSizer
+func (cache *Cache[K, V]) Size() int64 {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	return cache.size
+}
+
+// Clear removes all entries, stats are kept
+func (cache *Cache[K, V]) Clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for key := range cache.loads {
+		cache.invalidate(key)
+	}
+
+	clear(cache.entries)
+	cache.size = 0
+	cache.recency.Init()
+	cache.frequency.Clear()
+	cache.deadlines.Clear()
+}
+
+func (cache *Cache[K, V]) get(key K) (value V, found bool) {
+
+	entry, found := cache.entries[key]
+	if found && cache.expired(entry) {
+		cache.remove(entry)
+		cache.stats.Expirations++
+		found = false
+	}
+
+	if !found {
+		cache.stats.Misses++
+		return value, false
+	}
+
+	cache.stats.Hits++
+	cache.touch(entry)
+
+	return entry.value, true
+}
+
+func (cache *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+
+	cache.expire()
+
+	deadline := int64(never)
+	if ttl > 0 {
+		deadline = cache.options.Clock().Add(ttl).UnixNano()
+	}
+
+	size := int64(1)
+	if cache.sizer != nil {
+		size = cache.sizer(value)
+	}
+
+	entry, found := cache.entries[key]
+
+	// flushing the whole cache wouldn't make room for it anyway
+	if cache.options.MaxSize > 0 && size > cache.options.MaxSize {
+		if found {
+			cache.remove(entry)
+		}
+		return
+	}
+
+	if found {
+		cache.size -= entry.size
+		entry.value, entry.size = value, size
+		cache.touch(entry)
+	} else {
+		// make room first, so the new entry isn't the victim of LFU or TTL right away
+		cache.evict(1, size)
+
+		entry = &item[K, V]{key: key, value: value, size: size}
+		cache.entries[key] = entry
+		cache.index(entry)
+	}
+
+	cache.size += size
+	cache.setDeadline(entry, deadline)
+
+	cache.evict(0, 0)
+}
+
+// index adds a new entry to the index of the policy
+func (cache *Cache[K, V]) index(entry *item[K, V]) {
+
+	cache.tick++
+
+	switch cache.options.Policy {
+	case LRU:
+		entry.element = cache.recency.PushFront(entry)
+	case LFU:
+		entry.frequency = rank{priority: 1, tick: cache.tick}
+		cache.frequency.Insert(entry.frequency, entry)
+	}
+}
+
+// touch records an access to entry in the index of the policy
+func (cache *Cache[K, V]) touch(entry *item[K, V]) {
+
+	cache.tick++
+
+	switch cache.options.Policy {
+	case LRU:
+		cache.recency.MoveToFront(entry.element)
+	case LFU:
+		cache.frequency.Remove(entry.frequency)
+		entry.frequency = rank{priority: entry.frequency.priority + 1, tick: cache.tick}
+		cache.frequency.Insert(entry.frequency, entry)
+	}
+}
+
+// setDeadline moves entry in the deadlines, entries which never expire are left out unless under TTL policy
+func (cache *Cache[K, V]) setDeadline(entry *item[K, V], deadline int64) {
+
+	if entry.deadline.tick != 0 {
+		cache.deadlines.Remove(entry.deadline)
+		entry.deadline = rank{}
+	}
+
+	if deadline == never && cache.options.Policy != TTL {
+		return
+	}
+
+	entry.deadline = rank{priority: deadline, tick: cache.tick}
+	cache.deadlines.Insert(entry.deadline, entry)
+}
+
+func (cache *Cache[K, V]) remove(entry *item[K, V]) {
+
+	delete(cache.entries, entry.key)
+	cache.size -= entry.size
+
+	if entry.element != nil {
+		cache.recency.Remove(entry.element)
+	}
+
+	if entry.frequency.tick != 0 {
+		cache.frequency.Remove(entry.frequency)
+	}
+
+	if entry.deadline.tick != 0 {
+		cache.deadlines.Remove(entry.deadline)
+	}
+}
+
+func (cache *Cache[K, V]) expired(entry *item[K, V]) bool {
+	return entry.deadline.tick != 0 && entry.deadline.priority != never &&
+		entry.deadline.priority <= cache.options.Clock().UnixNano()
+}
+
+// expire removes the expired entries, the earliest deadlines come first
+func (cache *Cache[K, V]) expire() int {
+
+	count := 0
+
+	for node := cache.deadlines.Left(); node != nil && cache.expired(node.Value); node = cache.deadlines.Left() {
+		cache.remove(node.Value)
+		count++
+	}
+
+	cache.stats.Expirations += uint64(count)
+
+	return count
+}
+
+// evict removes the victims of the policy until the cache is within its limits,
+// counting the entries and size about to be added.
+func (cache *Cache[K, V]) evict(entries int, size int64) {
+
+	for len(cache.entries) > 0 &&
+		(cache.options.MaxEntries > 0 && len(cache.entries)+entries > cache.options.MaxEntries ||
+			cache.options.MaxSize > 0 && cache.size+size > cache.options.MaxSize) {
+
+		cache.remove(cache.victim())
+		cache.stats.Evictions++
+	}
+}
+
+// victim returns the entry to evict next, the cache must not be empty
+func (cache *Cache[K, V]) victim() *item[K, V] {
+	switch cache.options.Policy {
+	case LFU:
+		return cache.frequency.Left().Value
+	case TTL:
+		return cache.deadlines.Left().Value
+	default:
+		return cache.recency.Back().Value.(*item[K, V])
+	}
+}
@@ -0,0 +1,426 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-18 13:26:51
+ * @Description  :
+ */
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// clock is a fake clock moved by hand
+type clock struct {
+	now time.Time
+}
+
+func (clock *clock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *clock) Add(d time.Duration) {
+	clock.now = clock.now.Add(d)
+}
+
+func keys[K comparable, V any](cache *Cache[K, V], candidates ...K) string {
+	var found []K
+	for _, key := range candidates {
+		cache.mu.Lock()
+		if _, ok := cache.entries[key]; ok {
+			found = append(found, key)
+		}
+		cache.mu.Unlock()
+	}
+	return fmt.Sprint(found)
+}
+
+func TestCacheLRU(t *testing.T) {
+
+	cache := New[string, int](WithMaxEntries(3))
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+	cache.Get("a")
+	cache.Set("d", 4) // evicts b
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d"), "[a c d]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("c", 30) // overwrite, c is the most recent
+	cache.Set("e", 5)  // evicts a
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d", "e"), "[c d e]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if value, found := cache.Get("c"); value != 30 || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 30, true)
+	}
+
+	if value, found := cache.Get("a"); value != 0 || found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 0, false)
+	}
+
+	if !cache.Remove("d") || cache.Remove("d") || cache.Len() != 2 {
+		t.Errorf("Got %v entries expected %v", cache.Len(), 2)
+	}
+
+	stats := cache.Stats()
+	if actualValue, expectedValue := fmt.Sprint(stats.Hits, stats.Misses, stats.Evictions, stats.Entries, stats.Size, stats.HitRatio()), "2 1 2 2 2 0.6666666666666666"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Clear()
+
+	if cache.Len() != 0 || cache.Size() != 0 || cache.Stats().Hits != 2 {
+		t.Errorf("Got %v expected an empty cache keeping its stats", cache.Stats())
+	}
+
+	cache.Set("f", 6)
+
+	if value, found := cache.Get("f"); value != 6 || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 6, true)
+	}
+}
+
+func TestCacheLFU(t *testing.T) {
+
+	cache := New[string, int](WithPolicy(LFU), WithMaxEntries(3))
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Set("c", 3)
+
+	for i := 0; i < 3; i++ {
+		cache.Get("a")
+	}
+	cache.Get("c")
+	cache.Get("b")
+
+	cache.Set("d", 4) // b and c are used twice, c least recently
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d"), "[a b d]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("e", 5) // d was used once
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d", "e"), "[a b e]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Remove("a")
+	cache.Set("f", 6)
+	cache.Set("g", 7) // e and f are used once, e least recently
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d", "e", "f", "g"), "[b f g]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+
+	clock := &clock{now: time.Date(2021, 10, 18, 0, 0, 0, 0, time.UTC)}
+
+	for _, policy := range []Policy{LRU, LFU, TTL} {
+
+		cache := New[string, int](WithPolicy(policy), WithTTL(time.Minute), WithClock(clock.Now))
+
+		cache.Set("a", 1)
+		clock.Add(30 * time.Second)
+		cache.Set("b", 2)
+		cache.SetWithTTL("c", 3, 0) // never expires
+		clock.Add(30 * time.Second)
+
+		if value, found := cache.Get("a"); found {
+			t.Errorf("Got %v,%v expected expired for %v", value, found, policy)
+		}
+
+		if value, found := cache.Get("b"); value != 2 || !found {
+			t.Errorf("Got %v,%v expected %v,%v for %v", value, found, 2, true, policy)
+		}
+
+		cache.Set("b", 20) // a new time to live
+		clock.Add(45 * time.Second)
+
+		if actualValue, expectedValue := fmt.Sprint(cache.RemoveExpired(), " ", keys(cache, "a", "b", "c")), "0 [b c]"; actualValue != expectedValue {
+			t.Errorf("Got %v expected %v for %v", actualValue, expectedValue, policy)
+		}
+
+		clock.Add(15 * time.Second)
+
+		if actualValue, expectedValue := fmt.Sprint(cache.RemoveExpired(), " ", keys(cache, "a", "b", "c")), "1 [c]"; actualValue != expectedValue {
+			t.Errorf("Got %v expected %v for %v", actualValue, expectedValue, policy)
+		}
+
+		if stats := cache.Stats(); stats.Expirations != 2 || stats.Evictions != 0 {
+			t.Errorf("Got %v expected %v expirations for %v", stats, 2, policy)
+		}
+	}
+}
+
+func TestCacheTTLPolicy(t *testing.T) {
+
+	clock := &clock{now: time.Date(2021, 10, 18, 0, 0, 0, 0, time.UTC)}
+
+	cache := New[string, int](WithPolicy(TTL), WithMaxEntries(3), WithClock(clock.Now))
+
+	cache.SetWithTTL("a", 1, time.Hour)
+	cache.SetWithTTL("b", 2, time.Minute)
+	cache.Set("c", 3)
+	cache.SetWithTTL("d", 4, time.Second) // evicts b, the soonest to expire
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d"), "[a c d]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Get("d")
+	cache.Set("e", 5) // evicts d, reading doesn't extend the time to live
+
+	if actualValue, expectedValue := keys(cache, "a", "b", "c", "d", "e"), "[a c e]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("f", 6) // evicts a
+	cache.Set("g", 7) // evicts c, which never expires like e but was set before
+
+	if actualValue, expectedValue := keys(cache, "a", "c", "e", "f", "g"), "[e f g]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestCacheMaxSize(t *testing.T) {
+
+	cache := NewWithSizer[string](func(value string) int64 {
+		return int64(len(value) + 1)
+	}, WithMaxSize(10))
+
+	cache.Set("a", "1234")
+	cache.Set("b", "1234")
+
+	if actualValue, expectedValue := fmt.Sprint(cache.Len(), cache.Size()), "2 10"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("a", "12") // shrinks
+	cache.Set("c", "1")  // fits
+
+	if actualValue, expectedValue := fmt.Sprint(keys(cache, "a", "b", "c"), " ", cache.Size()), "[a b c] 10"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("d", "1234567") // evicts b and a
+
+	if actualValue, expectedValue := fmt.Sprint(keys(cache, "a", "b", "c", "d"), " ", cache.Size()), "[c d] 10"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("e", "12345678910") // larger than the cache, refused without evicting
+
+	if actualValue, expectedValue := fmt.Sprint(keys(cache, "c", "d", "e"), " ", cache.Size(), " ", cache.Stats().Evictions), "[c d] 10 2"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	cache.Set("c", "12345678910") // the former value isn't kept either
+
+	if actualValue, expectedValue := fmt.Sprint(keys(cache, "c", "d"), " ", cache.Size(), " ", cache.Stats().Evictions), "[d] 8 2"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+
+	cache := New[int, string]()
+
+	var calls int32
+	release := make(chan struct{})
+
+	loader := func(key int) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return fmt.Sprint("value", key), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := cache.GetOrLoad(1, loader)
+			if err != nil {
+				t.Errorf("Got error %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+
+	// wait for all callers to miss before the load completes
+	for cache.Stats().Misses < uint64(len(results)) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if actualValue, expectedValue := fmt.Sprint(atomic.LoadInt32(&calls), " ", results[0], " ", results[9]), "1 value1 value1"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// cached now
+	if value, err := cache.GetOrLoad(1, loader); value != "value1" || err != nil || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Got %v,%v expected %v,%v", value, err, "value1", nil)
+	}
+
+	// errors are not cached
+	failure := errors.New("unavailable")
+
+	if _, err := cache.GetOrLoad(2, func(key int) (string, error) { return "", failure }); !errors.Is(err, failure) {
+		t.Errorf("Got %v expected %v", err, failure)
+	}
+
+	if _, found := cache.Get(2); found {
+		t.Errorf("Got %v expected %v", found, false)
+	}
+
+	// a panicking loader doesn't block the key
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected the panic of the loader")
+			}
+		}()
+		cache.GetOrLoad(3, func(key int) (string, error) { panic("boom") })
+	}()
+
+	if value, err := cache.GetOrLoad(3, func(key int) (string, error) { return "3", nil }); value != "3" || err != nil {
+		t.Errorf("Got %v,%v expected %v,%v", value, err, "3", nil)
+	}
+
+	stats := cache.Stats()
+	if actualValue, expectedValue := fmt.Sprint(stats.Loads, stats.LoadErrors, stats.Hits, stats.Entries), "4 2 1 2"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestCacheGetOrLoadInvalidated(t *testing.T) {
+
+	cache := New[int, string]()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	loader := func(key int) (string, error) {
+		started <- struct{}{}
+		<-release
+		return "loaded", nil
+	}
+
+	tests := []struct {
+		write    func()
+		expected string
+	}{
+		{func() { cache.Remove(1) }, " false"},
+		{func() { cache.Set(1, "set") }, "set true"},
+		{func() { cache.Clear() }, " false"},
+	}
+
+	for _, test := range tests {
+		cache.Remove(1)
+
+		done := make(chan string)
+		go func() {
+			value, _ := cache.GetOrLoad(1, loader)
+			done <- value
+		}()
+
+		// the key is written while the loader is blocked
+		<-started
+		test.write()
+		release <- struct{}{}
+
+		if value := <-done; value != "loaded" {
+			t.Errorf("Got %v expected %v", value, "loaded")
+		}
+
+		// the loaded value doesn't undo the write
+		if value, found := cache.Get(1); fmt.Sprint(value, " ", found) != test.expected {
+			t.Errorf("Got %v %v expected %v", value, found, test.expected)
+		}
+	}
+}
+
+func TestCacheCollector(t *testing.T) {
+
+	cache := New[string, int](WithMaxEntries(1))
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("b")
+	cache.Get("a")
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(cache.Collector("users"))
+
+	expected := `
+# HELP cache_entries Current number of entries.
+# TYPE cache_entries gauge
+cache_entries{cache="users"} 1
+# HELP cache_evictions_total Number of entries evicted to stay within the limits.
+# TYPE cache_evictions_total counter
+cache_evictions_total{cache="users"} 1
+# HELP cache_hits_total Number of lookups finding the key.
+# TYPE cache_hits_total counter
+cache_hits_total{cache="users"} 1
+# HELP cache_misses_total Number of lookups missing the key.
+# TYPE cache_misses_total counter
+cache_misses_total{cache="users"} 1
+`
+
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "cache_entries", "cache_evictions_total", "cache_hits_total", "cache_misses_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+
+	cache := New[int, int](WithPolicy(LFU), WithMaxEntries(50), WithTTL(time.Hour))
+
+	var wg sync.WaitGroup
+
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := (i * (worker + 1)) % 100
+				switch i % 4 {
+				case 0:
+					cache.Set(key, i)
+				case 1:
+					cache.Remove(key)
+				default:
+					cache.GetOrLoad(key, func(key int) (int, error) { return key, nil })
+				}
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+
+	if cache.Len() > 50 || int64(cache.Len()) != cache.Size() {
+		t.Errorf("Got %v entries of size %v expected at most %v", cache.Len(), cache.Size(), 50)
+	}
+
+	if err := cache.frequency.Validate(); err != nil || cache.frequency.Size() != cache.Len() || cache.deadlines.Size() != cache.Len() {
+		t.Errorf("Got %v with %d,%d indexed entries expected %d", err, cache.frequency.Size(), cache.deadlines.Size(), cache.Len())
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-18 11:12:26
+ * @Description  : loading missing entries once for concurrent callers
+ */
+package cache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// call is a load in flight, callers of the same key wait for it instead of loading again
+type call[V any] struct {
+	done  sync.WaitGroup
+	value V
+	err   error
+	stale bool // key was set or removed during the load, the loaded value is not cached
+}
+
+// GetOrLoad returns the value of key, loading and caching it by loader if key is not cached or expired.
+// Concurrent callers missing the same key share a single call of loader and its result,
+// an error is returned to all of them and nothing is cached.
+// The loaded value isn't cached either if key is set or removed (or the cache cleared) while loader runs,
+// so an explicit invalidation is never undone by a load started before it.
+// A panic of loader goes on in the caller running it, the others get an error.
+func (cache *Cache[K, V]) GetOrLoad(key K, loader func(key K) (V, error)) (V, error) {
+
+	cache.mu.Lock()
+
+	if value, found := cache.get(key); found {
+		cache.mu.Unlock()
+		return value, nil
+	}
+
+	if load, found := cache.loads[key]; found {
+		cache.mu.Unlock()
+		load.done.Wait()
+		return load.value, load.err
+	}
+
+	load := &call[V]{}
+	load.done.Add(1)
+	cache.loads[key] = load
+	cache.stats.Loads++
+
+	cache.mu.Unlock()
+
+	cache.load(key, load, loader)
+
+	return load.value, load.err
+}
+
+// load runs loader and publishes its result, caching the value unless loading failed
+func (cache *Cache[K, V]) load(key K, load *call[V], loader func(key K) (V, error)) {
+
+	// the error stays if loader panics
+	load.err = fmt.Errorf("cache: loader of %v panicked", key)
+
+	defer func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+
+		delete(cache.loads, key)
+
+		if load.err != nil {
+			cache.stats.LoadErrors++
+		} else if !load.stale {
+			cache.set(key, load.value, cache.options.TTL)
+		}
+
+		load.done.Done()
+	}()
+
+	load.value, load.err = loader(key)
+}
+
+// invalidate keeps the load in flight of key, if any, from caching its value, which predates a write of key
+func (cache *Cache[K, V]) invalidate(key K) {
+	if load, found := cache.loads[key]; found {
+		load.stale = true
+	}
+}
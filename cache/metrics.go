@@ -0,0 +1,101 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-18 11:40:08
+ * @Description  : cache statistics and their prometheus collector
+ */
+package cache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Stats counts the operations of a cache since it was created
+type Stats struct {
+	Hits        uint64 // Get and GetOrLoad finding the key
+	Misses      uint64 // Get and GetOrLoad missing the key, expired keys included
+	Loads       uint64 // calls of loaders, concurrent misses of a key share one
+	LoadErrors  uint64 // loaders returning an error or panicking
+	Evictions   uint64 // entries removed to stay within the limits
+	Expirations uint64 // entries removed after their time to live
+	Entries     int    // current number of entries
+	Size        int64  // current total size of entries
+}
+
+// HitRatio returns the share of hits among lookups, 0 if there was no lookup
+func (stats Stats) HitRatio() float64 {
+	if stats.Hits+stats.Misses == 0 {
+		return 0
+	}
+
+	return float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+}
+
+// Stats returns the statistics of the cache
+func (cache *Cache[K, V]) Stats() Stats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	stats := cache.stats
+	stats.Entries, stats.Size = len(cache.entries), cache.size
+
+	return stats
+}
+
+// Collector returns a prometheus collector exporting the stats of the cache labelled with cache=name,
+// register it before serving the metrics, e.g.
+//
+//	prometheus.MustRegister(users.Collector("users"))
+//	common.PrometheusBoot(9100)
+func (cache *Cache[K, V]) Collector(name string) prometheus.Collector {
+
+	labels := prometheus.Labels{"cache": name}
+
+	return &collector{
+		stats:       cache.Stats,
+		hits:        prometheus.NewDesc("cache_hits_total", "Number of lookups finding the key.", nil, labels),
+		misses:      prometheus.NewDesc("cache_misses_total", "Number of lookups missing the key.", nil, labels),
+		loads:       prometheus.NewDesc("cache_loads_total", "Number of loader calls.", nil, labels),
+		loadErrors:  prometheus.NewDesc("cache_load_errors_total", "Number of failed loader calls.", nil, labels),
+		evictions:   prometheus.NewDesc("cache_evictions_total", "Number of entries evicted to stay within the limits.", nil, labels),
+		expirations: prometheus.NewDesc("cache_expirations_total", "Number of entries removed after their time to live.", nil, labels),
+		entries:     prometheus.NewDesc("cache_entries", "Current number of entries.", nil, labels),
+		size:        prometheus.NewDesc("cache_size", "Current total size of entries.", nil, labels),
+	}
+}
+
+// collector reads the stats of a cache on every scrape
+type collector struct {
+	stats func() Stats
+
+	hits, misses, loads, loadErrors, evictions, expirations, entries, size *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector
+func (collector *collector) Describe(descs chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		collector.hits, collector.misses, collector.loads, collector.loadErrors,
+		collector.evictions, collector.expirations, collector.entries, collector.size,
+	} {
+		descs <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (collector *collector) Collect(metrics chan<- prometheus.Metric) {
+
+	stats := collector.stats()
+
+	counters := map[*prometheus.Desc]uint64{
+		collector.hits:        stats.Hits,
+		collector.misses:      stats.Misses,
+		collector.loads:       stats.Loads,
+		collector.loadErrors:  stats.LoadErrors,
+		collector.evictions:   stats.Evictions,
+		collector.expirations: stats.Expirations,
+	}
+
+	for desc, value := range counters {
+		metrics <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value))
+	}
+
+	metrics <- prometheus.MustNewConstMetric(collector.entries, prometheus.GaugeValue, float64(stats.Entries))
+	metrics <- prometheus.MustNewConstMetric(collector.size, prometheus.GaugeValue, float64(stats.Size))
+}
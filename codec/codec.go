@@ -0,0 +1,185 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-22 10:03:17
+ * @Description  : codec.go provides typed binary encoding of keys and values
+ */
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Codec encodes a value of type T into bytes and decodes it back,
+// Decode(Encode(v)) should give a value equal to v so that keys keep their type and order.
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// Func adapts a pair of functions into a Codec
+type Func[T any] struct {
+	EncodeFunc func(value T) ([]byte, error)
+	DecodeFunc func(data []byte) (T, error)
+}
+
+// Encode calls EncodeFunc
+func (c Func[T]) Encode(value T) ([]byte, error) {
+	return c.EncodeFunc(value)
+}
+
+// Decode calls DecodeFunc
+func (c Func[T]) Decode(data []byte) (T, error) {
+	return c.DecodeFunc(data)
+}
+
+// ErrCorrupted is returned when data can't be decoded by the codec
+var ErrCorrupted = errors.New("codec: corrupted data")
+
+var (
+	// Int encodes int as varint
+	Int Codec[int] = Func[int]{
+		EncodeFunc: func(value int) ([]byte, error) {
+			return binary.AppendVarint(nil, int64(value)), nil
+		},
+		DecodeFunc: func(data []byte) (int, error) {
+			value, err := decodeVarint(data)
+			return int(value), err
+		},
+	}
+
+	// Int64 encodes int64 as varint
+	Int64 Codec[int64] = Func[int64]{
+		EncodeFunc: func(value int64) ([]byte, error) {
+			return binary.AppendVarint(nil, value), nil
+		},
+		DecodeFunc: decodeVarint,
+	}
+
+	// Uint encodes uint as uvarint
+	Uint Codec[uint] = Func[uint]{
+		EncodeFunc: func(value uint) ([]byte, error) {
+			return binary.AppendUvarint(nil, uint64(value)), nil
+		},
+		DecodeFunc: func(data []byte) (uint, error) {
+			value, err := decodeUvarint(data)
+			return uint(value), err
+		},
+	}
+
+	// Uint64 encodes uint64 as uvarint
+	Uint64 Codec[uint64] = Func[uint64]{
+		EncodeFunc: func(value uint64) ([]byte, error) {
+			return binary.AppendUvarint(nil, value), nil
+		},
+		DecodeFunc: decodeUvarint,
+	}
+
+	// Float64 encodes float64 as its 8 bytes IEEE 754 representation
+	Float64 Codec[float64] = Func[float64]{
+		EncodeFunc: func(value float64) ([]byte, error) {
+			return binary.BigEndian.AppendUint64(nil, math.Float64bits(value)), nil
+		},
+		DecodeFunc: func(data []byte) (float64, error) {
+			if len(data) != 8 {
+				return 0, fmt.Errorf("%w: float64 takes 8 bytes, got %d", ErrCorrupted, len(data))
+			}
+
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+		},
+	}
+
+	// Bool encodes bool as a single byte
+	Bool Codec[bool] = Func[bool]{
+		EncodeFunc: func(value bool) ([]byte, error) {
+			if value {
+				return []byte{1}, nil
+			}
+
+			return []byte{0}, nil
+		},
+		DecodeFunc: func(data []byte) (bool, error) {
+			if len(data) != 1 || data[0] > 1 {
+				return false, fmt.Errorf("%w: invalid bool", ErrCorrupted)
+			}
+
+			return data[0] == 1, nil
+		},
+	}
+
+	// String encodes string as its raw bytes
+	String Codec[string] = Func[string]{
+		EncodeFunc: func(value string) ([]byte, error) {
+			return []byte(value), nil
+		},
+		DecodeFunc: func(data []byte) (string, error) {
+			return string(data), nil
+		},
+	}
+
+	// Bytes encodes []byte as is, decoded slices are copied
+	Bytes Codec[[]byte] = Func[[]byte]{
+		EncodeFunc: func(value []byte) ([]byte, error) {
+			return value, nil
+		},
+		DecodeFunc: func(data []byte) ([]byte, error) {
+			return append([]byte{}, data...), nil
+		},
+	}
+
+	// Time encodes time.Time by its MarshalBinary, which keeps the location offset
+	Time Codec[time.Time] = Func[time.Time]{
+		EncodeFunc: func(value time.Time) ([]byte, error) {
+			return value.MarshalBinary()
+		},
+		DecodeFunc: func(data []byte) (time.Time, error) {
+			var value time.Time
+			err := value.UnmarshalBinary(data)
+			return value, err
+		},
+	}
+)
+
+// Gob returns a codec using encoding/gob, which works for any gob-encodable type.
+// For interface types (e.g. keys of a tree built with comparator.Comparator)
+// the dynamic type is kept, concrete types other than the builtin ones should be registered by gob.Register.
+func Gob[T any]() Codec[T] {
+	return Func[T]{
+		EncodeFunc: func(value T) ([]byte, error) {
+			var buffer bytes.Buffer
+			if err := gob.NewEncoder(&buffer).Encode(&value); err != nil {
+				return nil, err
+			}
+
+			return buffer.Bytes(), nil
+		},
+		DecodeFunc: func(data []byte) (T, error) {
+			var value T
+			err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+			return value, err
+		},
+	}
+}
+
+func decodeVarint(data []byte) (int64, error) {
+	value, n := binary.Varint(data)
+	if n <= 0 || n != len(data) {
+		return 0, fmt.Errorf("%w: invalid varint", ErrCorrupted)
+	}
+
+	return value, nil
+}
+
+func decodeUvarint(data []byte) (uint64, error) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 || n != len(data) {
+		return 0, fmt.Errorf("%w: invalid uvarint", ErrCorrupted)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-22 10:41:08
+ * @Description  :
+ */
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func roundTrip[T any](t *testing.T, c Codec[T], value T) T {
+	data, err := c.Encode(value)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	actual, err := c.Decode(data)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	return actual
+}
+
+func TestCodecs(t *testing.T) {
+
+	for _, value := range []int{0, 1, -1, math.MaxInt, math.MinInt} {
+		if actual := roundTrip(t, Int, value); actual != value {
+			t.Errorf("Got %v expected %v", actual, value)
+		}
+	}
+
+	for _, value := range []uint64{0, 1, math.MaxUint64} {
+		if actual := roundTrip(t, Uint64, value); actual != value {
+			t.Errorf("Got %v expected %v", actual, value)
+		}
+	}
+
+	for _, value := range []float64{0, -1.5, math.Inf(1), math.SmallestNonzeroFloat64} {
+		if actual := roundTrip(t, Float64, value); actual != value {
+			t.Errorf("Got %v expected %v", actual, value)
+		}
+	}
+
+	for _, value := range []bool{true, false} {
+		if actual := roundTrip(t, Bool, value); actual != value {
+			t.Errorf("Got %v expected %v", actual, value)
+		}
+	}
+
+	if actual, expected := roundTrip(t, String, "héllo"), "héllo"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+
+	if actual, expected := roundTrip(t, Bytes, []byte{0, 1, 2}), []byte{0, 1, 2}; !bytes.Equal(actual, expected) {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+
+	now := time.Date(2021, 9, 22, 10, 41, 8, 5, time.FixedZone("CST", 8*3600))
+	if actual := roundTrip(t, Time, now); !actual.Equal(now) {
+		t.Errorf("Got %v expected %v", actual, now)
+	}
+}
+
+func TestCodecCorrupted(t *testing.T) {
+
+	if _, err := Int.Decode([]byte{0x80}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Got %v expected %v", err, ErrCorrupted)
+	}
+
+	if _, err := Int.Decode([]byte{2, 2}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Got %v expected %v", err, ErrCorrupted)
+	}
+
+	if _, err := Float64.Decode([]byte{1}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Got %v expected %v", err, ErrCorrupted)
+	}
+
+	if _, err := Bool.Decode([]byte{2}); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Got %v expected %v", err, ErrCorrupted)
+	}
+}
+
+func TestGob(t *testing.T) {
+
+	// dynamic types of interface values are kept
+	c := Gob[interface{}]()
+	for _, value := range []interface{}{1, int64(1), "1", 1.0, uint8(1)} {
+		if actual := roundTrip(t, c, value); actual != value {
+			t.Errorf("Got %T %v expected %T %v", actual, actual, value, value)
+		}
+	}
+
+	type pair struct {
+		A int
+		B string
+	}
+
+	if actual, expected := roundTrip(t, Gob[pair](), pair{1, "b"}), (pair{1, "b"}); actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-12 15:03:44
+ * @Description  : auto.go provides a comparator over mixed primitive keys
+ */
+package comparator
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kinds of keys ordered by Auto, a key of a smaller kind comes first
+const (
+	autoNil = iota
+	autoBool
+	autoNumber
+	autoString
+	autoTime
+)
+
+// kinds of numbers, each compared exactly against the others
+const (
+	numberInt = iota
+	numberUint
+	numberFloat
+)
+
+var errUnsupported = errors.New("unsupported by Auto")
+
+type number struct {
+	kind int
+	i    int64
+	u    uint64
+	f    float64
+}
+
+// Auto provides a comparison on keys of mixed primitive types, e.g. decoded from JSON.
+// Keys of different kinds are ordered as nil < bool < number < string < time.Time, and within a kind:
+//
+//	bool     : false < true
+//	number   : all int, uint and float types and json.Number by their numerical value,
+//	           so 1, int64(1), 1.0 and json.Number("1") are equal, NaN comes before any other number
+//	string   : byte-wise like StringComparator
+//	time.Time: chronologically like TimeComparator
+//
+// Other types, or a json.Number not holding a number, panic with a *KeyTypeError,
+// which Safe and the Try methods of the trees report as an error.
+func Auto(a, b interface{}) int {
+
+	aKind, bKind := autoKind(a), autoKind(b)
+	if aKind != bKind {
+		return compareInt(aKind, bKind)
+	}
+
+	switch aKind {
+	case autoBool:
+		return compareBool(a.(bool), b.(bool))
+	case autoNumber:
+		return compareNumber(toNumber(a), toNumber(b))
+	case autoString:
+		return strings.Compare(a.(string), b.(string))
+	case autoTime:
+		return a.(time.Time).Compare(b.(time.Time))
+	default:
+		return 0
+	}
+}
+
+func autoKind(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return autoNil
+	case bool:
+		return autoBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64, json.Number:
+		return autoNumber
+	case string:
+		return autoString
+	case time.Time:
+		return autoTime
+	default:
+		panic(&KeyTypeError{Key: value, Cause: errUnsupported})
+	}
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func toNumber(value interface{}) number {
+	switch n := value.(type) {
+	case int:
+		return number{kind: numberInt, i: int64(n)}
+	case int8:
+		return number{kind: numberInt, i: int64(n)}
+	case int16:
+		return number{kind: numberInt, i: int64(n)}
+	case int32:
+		return number{kind: numberInt, i: int64(n)}
+	case int64:
+		return number{kind: numberInt, i: n}
+	case uint:
+		return number{kind: numberUint, u: uint64(n)}
+	case uint8:
+		return number{kind: numberUint, u: uint64(n)}
+	case uint16:
+		return number{kind: numberUint, u: uint64(n)}
+	case uint32:
+		return number{kind: numberUint, u: uint64(n)}
+	case uint64:
+		return number{kind: numberUint, u: n}
+	case uintptr:
+		return number{kind: numberUint, u: uint64(n)}
+	case float32:
+		return number{kind: numberFloat, f: float64(n)}
+	case float64:
+		return number{kind: numberFloat, f: n}
+	default:
+		return parseNumber(value.(json.Number))
+	}
+}
+
+// parseNumber keeps integers of json.Number exact instead of rounding them to float64
+func parseNumber(n json.Number) number {
+
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return number{kind: numberInt, i: i}
+	}
+
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return number{kind: numberUint, u: u}
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		panic(&KeyTypeError{Key: n, Cause: err})
+	}
+
+	return number{kind: numberFloat, f: f}
+}
+
+func compareNumber(a, b number) int {
+
+	if a.kind > b.kind {
+		return -compareNumber(b, a)
+	}
+
+	switch {
+	case a.kind == numberInt && b.kind == numberInt:
+		return cmp.Compare(a.i, b.i)
+	case a.kind == numberUint && b.kind == numberUint:
+		return cmp.Compare(a.u, b.u)
+	case a.kind == numberFloat:
+		return cmp.Compare(a.f, b.f)
+	case a.kind == numberInt && b.kind == numberUint:
+		if a.i < 0 {
+			return -1
+		}
+		return cmp.Compare(uint64(a.i), b.u)
+	case a.kind == numberInt:
+		return -compareFloatInt(b.f, a.i)
+	default:
+		return -compareFloatUint(b.f, a.u)
+	}
+}
+
+// compareFloatInt compares f and i without rounding i to float64
+func compareFloatInt(f float64, i int64) int {
+	switch {
+	case math.IsNaN(f), f < math.MinInt64:
+		return -1
+	case f >= math.MaxInt64: // 2^63 as float64
+		return 1
+	}
+
+	truncated := int64(f)
+	if truncated != i {
+		return cmp.Compare(truncated, i)
+	}
+
+	return cmp.Compare(f, float64(truncated))
+}
+
+// compareFloatUint compares f and u without rounding u to float64
+func compareFloatUint(f float64, u uint64) int {
+	switch {
+	case math.IsNaN(f), f < 0:
+		return -1
+	case f >= math.MaxUint64: // 2^64 as float64
+		return 1
+	}
+
+	truncated := uint64(f)
+	if truncated != u {
+		return cmp.Compare(truncated, u)
+	}
+
+	return cmp.Compare(f, float64(truncated))
+}
@@ -0,0 +1,50 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-08 10:26:03
+ * @Description  : collation.go provides locale-aware string comparison
+ */
+package comparator
+
+import (
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// CollationComparator returns a comparator ordering strings by the Unicode collation rules of locale,
+// e.g. "zh" orders Chinese by pinyin, "en", "fr" and "de" put accented and mixed-case letters next to their base letter
+// instead of after "z" as StringComparator does.
+// Options such as collate.Numeric tailor the collation further.
+//
+// Strings the collation finds equal are ordered by StringComparator, so distinct strings stay distinct keys of a tree.
+// The comparator is safe for concurrent use.
+func CollationComparator(locale string, options ...collate.Option) (Comparator, error) {
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	// a collator keeps buffers between calls, so every goroutine takes its own
+	collators := sync.Pool{
+		New: func() interface{} {
+			return collate.New(tag, options...)
+		},
+	}
+
+	return func(a, b interface{}) int {
+		s1 := a.(string)
+		s2 := b.(string)
+
+		collator := collators.Get().(*collate.Collator)
+		diff := collator.CompareString(s1, s2)
+		collators.Put(collator)
+
+		if diff != 0 {
+			return diff
+		}
+
+		return StringComparator(s1, s2)
+	}, nil
+}
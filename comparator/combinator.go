@@ -0,0 +1,184 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-30 10:18:42
+ * @Description  : combinator.go builds comparators out of other comparators
+ */
+package comparator
+
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Reverse returns a comparator ordering the opposite way of c
+func Reverse(c Comparator) Comparator {
+	return func(a, b interface{}) int {
+		return c(b, a)
+	}
+}
+
+// Chain returns a comparator trying comparators in turn until one tells a and b apart,
+// e.g. Chain(By(lastName, StringComparator), By(firstName, StringComparator)) for multi-field ordering.
+func Chain(comparators ...Comparator) Comparator {
+	return func(a, b interface{}) int {
+		for _, c := range comparators {
+			if diff := c(a, b); diff != 0 {
+				return diff
+			}
+		}
+
+		return 0
+	}
+}
+
+// By returns a comparator ordering values by c on what extract returns for them,
+// e.g. By(func(user interface{}) interface{} { return user.(User).Age }, IntComparator)
+func By(extract func(value interface{}) interface{}, c Comparator) Comparator {
+	return func(a, b interface{}) int {
+		return c(extract(a), extract(b))
+	}
+}
+
+// NilsFirst returns a comparator ordering nil before any other value, which are ordered by c.
+// Both untyped nil and nil pointers, maps, slices... are nil.
+func NilsFirst(c Comparator) Comparator {
+	return nils(c, -1)
+}
+
+// NilsLast returns a comparator ordering nil after any other value, which are ordered by c
+func NilsLast(c Comparator) Comparator {
+	return nils(c, 1)
+}
+
+func nils(c Comparator, order int) Comparator {
+	return func(a, b interface{}) int {
+		aNil, bNil := isNil(a), isNil(b)
+
+		switch {
+		case aNil && bNil:
+			return 0
+		case aNil:
+			return order
+		case bNil:
+			return -order
+		default:
+			return c(a, b)
+		}
+	}
+}
+
+func isNil(value interface{}) bool {
+
+	if value == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// CaseInsensitiveStringComparator compares strings rune by rune ignoring case,
+// so "Apple" and "apple" are equal and both come before "banana".
+func CaseInsensitiveStringComparator(a, b interface{}) int {
+	s1 := a.(string)
+	s2 := b.(string)
+
+	for s1 != "" && s2 != "" {
+		r1, size1 := utf8.DecodeRuneInString(s1)
+		r2, size2 := utf8.DecodeRuneInString(s2)
+
+		if diff := compareRune(unicode.ToLower(r1), unicode.ToLower(r2)); diff != 0 {
+			return diff
+		}
+
+		s1, s2 = s1[size1:], s2[size2:]
+	}
+
+	return compareInt(len(s1), len(s2))
+}
+
+// NaturalStringComparator compares strings with runs of digits ordered by their numeric value,
+// so "file9" comes before "file10".
+// Strings equal but for leading zeros are ordered by StringComparator, so "file01" and "file1" stay distinct keys.
+func NaturalStringComparator(a, b interface{}) int {
+	s1 := a.(string)
+	s2 := b.(string)
+
+	i, j := 0, 0
+
+	for i < len(s1) && j < len(s2) {
+
+		if isDigit(s1[i]) && isDigit(s2[j]) {
+			start1, start2 := i, j
+
+			for i < len(s1) && isDigit(s1[i]) {
+				i++
+			}
+
+			for j < len(s2) && isDigit(s2[j]) {
+				j++
+			}
+
+			if diff := compareDigits(s1[start1:i], s2[start2:j]); diff != 0 {
+				return diff
+			}
+
+			continue
+		}
+
+		if s1[i] != s2[j] {
+			return compareInt(int(s1[i]), int(s2[j]))
+		}
+
+		i++
+		j++
+	}
+
+	if diff := compareInt(len(s1)-i, len(s2)-j); diff != 0 {
+		return diff
+	}
+
+	return StringComparator(s1, s2)
+}
+
+// compareDigits compares two runs of decimal digits by their value without parsing, so any length works
+func compareDigits(d1, d2 string) int {
+
+	for len(d1) > 1 && d1[0] == '0' {
+		d1 = d1[1:]
+	}
+
+	for len(d2) > 1 && d2[0] == '0' {
+		d2 = d2[1:]
+	}
+
+	if len(d1) != len(d2) {
+		return compareInt(len(d1), len(d2))
+	}
+
+	return StringComparator(d1, d2)
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func compareRune(a, b rune) int {
+	return compareInt(int(a), int(b))
+}
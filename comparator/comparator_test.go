@@ -6,6 +6,14 @@
 package comparator
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
 	"testing"
 	"time"
 )
@@ -113,3 +121,369 @@ func TestCustomComparator(t *testing.T) {
 		}
 	}
 }
+
+func TestReverseAndChain(t *testing.T) {
+
+	type Person struct {
+		Last  string
+		First string
+		Age   *int
+	}
+
+	age := func(years int) *int { return &years }
+
+	people := []Person{
+		{"Li", "Wei", age(30)},
+		{"Chen", "Jie", nil},
+		{"Li", "An", age(25)},
+		{"Chen", "Bo", age(41)},
+	}
+
+	last := By(func(p interface{}) interface{} { return p.(Person).Last }, StringComparator)
+	first := By(func(p interface{}) interface{} { return p.(Person).First }, StringComparator)
+	byName := Chain(last, first)
+
+	sort.Slice(people, func(i, j int) bool { return byName(people[i], people[j]) < 0 })
+	if actual, expected := fmt.Sprint(people[0].First, people[1].First, people[2].First, people[3].First), "BoJieAnWei"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+
+	sort.Slice(people, func(i, j int) bool { return Reverse(byName)(people[i], people[j]) < 0 })
+	if actual, expected := fmt.Sprint(people[0].First, people[1].First, people[2].First, people[3].First), "WeiAnJieBo"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+
+	// ages with nil pointers first or last
+	byAge := By(func(p interface{}) interface{} { return p.(Person).Age }, NilsFirst(func(a, b interface{}) int {
+		return IntComparator(*a.(*int), *b.(*int))
+	}))
+	sort.Slice(people, func(i, j int) bool { return byAge(people[i], people[j]) < 0 })
+	if actual, expected := fmt.Sprint(people[0].First, people[1].First, people[2].First, people[3].First), "JieAnWeiBo"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+
+	// a, b, expected
+	tests := [][]interface{}{
+		{nil, nil, 0},
+		{nil, 1, 1},
+		{1, nil, -1},
+		{1, 2, -1},
+	}
+	for _, test := range tests {
+		actual := NilsLast(IntComparator)(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestCaseInsensitiveStringComparator(t *testing.T) {
+
+	// s1,s2,expected
+	tests := [][]interface{}{
+		{"apple", "Apple", 0},
+		{"Apple", "banana", -1},
+		{"apple", "BANANA", -1},
+		{"Zebra", "apple", 1},
+		{"ÉCOLE", "école", 0},
+		{"ab", "AbC", -1},
+		{"", "", 0},
+	}
+
+	for _, test := range tests {
+		actual := CaseInsensitiveStringComparator(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestNaturalStringComparator(t *testing.T) {
+
+	// s1,s2,expected
+	tests := [][]interface{}{
+		{"file9", "file10", -1},
+		{"file10", "file9", 1},
+		{"file10", "file10", 0},
+		{"file1", "file01", 1},
+		{"file01", "file1", -1},
+		{"file2a", "file2b", -1},
+		{"file2", "file2a", -1},
+		{"v1.10.0", "v1.9.3", 1},
+		{"a100000000000000000000000000", "a99999999999999999999999999", 1},
+		{"10", "9", 1},
+		{"x", "10", 1},
+		{"", "0", -1},
+	}
+
+	for _, test := range tests {
+		actual := NaturalStringComparator(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+
+	files := []string{"img12.png", "img10.png", "IMG2.png", "img2.png", "img1.png"}
+	sort.Slice(files, func(i, j int) bool { return NaturalStringComparator(files[i], files[j]) < 0 })
+	if actual, expected := fmt.Sprint(files), "[IMG2.png img1.png img2.png img10.png img12.png]"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+}
+
+func TestCollationComparator(t *testing.T) {
+
+	// locale, known-sorted fixture
+	tests := []struct {
+		locale string
+		sorted []string
+	}{
+		{"zh", []string{"阿明", "北京", "陈", "广州", "李四", "上海", "王五", "张三", "赵六", "中国"}},
+		{"en", []string{"Ångström", "apple", "Apple", "banana", "Banana", "café", "cafés", "cherry", "zebra"}},
+		{"fr", []string{"cote", "Cote", "coté", "côte", "côté", "élève", "Élève", "être", "zèbre"}},
+		{"de", []string{"Ähre", "apfel", "Äpfel", "bar", "Bär", "Mueller", "Muller", "Müller", "Strasse", "Straße", "Zebra"}},
+	}
+
+	for _, test := range tests {
+		c, err := CollationComparator(test.locale)
+		if err != nil {
+			t.Fatalf("Got error %v", err)
+		}
+
+		shuffled := append([]string(nil), test.sorted...)
+		for i := range shuffled {
+			j := (i*7 + 3) % len(shuffled)
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		}
+
+		sort.Slice(shuffled, func(i, j int) bool { return c(shuffled[i], shuffled[j]) < 0 })
+		if actual, expected := fmt.Sprint(shuffled), fmt.Sprint(test.sorted); actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test.locale)
+		}
+	}
+
+	if _, err := CollationComparator("not a locale!"); err == nil {
+		t.Errorf("Got %v expected error", err)
+	}
+}
+
+func TestBytesComparator(t *testing.T) {
+
+	// b1,b2,expected
+	tests := [][]interface{}{
+		{[]byte{}, []byte{}, 0},
+		{[]byte(nil), []byte{}, 0},
+		{[]byte{1, 2}, []byte{1, 2}, 0},
+		{[]byte{1, 2}, []byte{1, 3}, -1},
+		{[]byte{1, 2, 0}, []byte{1, 2}, 1},
+		{[]byte{0xff}, []byte{1, 2}, 1},
+	}
+
+	for _, test := range tests {
+		actual := BytesComparator(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestIPComparator(t *testing.T) {
+
+	// ip1,ip2,expected
+	tests := [][]interface{}{
+		{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.1"), 0},
+		{net.ParseIP("10.0.0.1").To4(), net.ParseIP("10.0.0.1"), 0},
+		{net.ParseIP("10.0.0.2"), net.ParseIP("10.0.0.10"), -1},
+		{net.ParseIP("192.168.0.1"), net.ParseIP("10.0.0.1"), 1},
+		{net.ParseIP("255.255.255.255"), net.ParseIP("::1"), -1},
+		{net.ParseIP("::1"), net.ParseIP("::2"), -1},
+		{net.ParseIP("fe80::1"), net.ParseIP("2001:db8::1"), 1},
+		{netip.MustParseAddr("10.0.0.1"), net.ParseIP("10.0.0.1"), 0},
+		{netip.MustParseAddr("::ffff:10.0.0.1"), netip.MustParseAddr("10.0.0.2"), -1},
+	}
+
+	for _, test := range tests {
+		actual := IPComparator(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestBigIntComparator(t *testing.T) {
+
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	// i1,i2,expected
+	tests := [][]interface{}{
+		{big.NewInt(1), big.NewInt(1), 0},
+		{big.NewInt(-1), big.NewInt(1), -1},
+		{huge, big.NewInt(math.MaxInt64), 1},
+		{new(big.Int).Neg(huge), big.NewInt(math.MinInt64), -1},
+	}
+
+	for _, test := range tests {
+		actual := BigIntComparator(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestDurationComparator(t *testing.T) {
+
+	// d1,d2,expected
+	tests := [][]interface{}{
+		{time.Second, time.Second, 0},
+		{time.Second, time.Minute, -1},
+		{time.Hour, -time.Hour, 1},
+	}
+
+	for _, test := range tests {
+		actual := DurationComparator(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestTupleComparator(t *testing.T) {
+
+	c := TupleComparator(StringComparator, Reverse(IntComparator), BytesComparator)
+
+	// t1,t2,expected
+	tests := [][]interface{}{
+		{[]interface{}{"a", 1, []byte{1}}, []interface{}{"a", 1, []byte{1}}, 0},
+		{[]interface{}{"a", 1}, []interface{}{"b", 0}, -1},
+		{[]interface{}{"a", 1}, []interface{}{"a", 2}, 1},
+		{[]interface{}{"a", 1}, []interface{}{"a", 1, []byte{}}, -1},
+		{[]interface{}{"a", 1, []byte{2}}, []interface{}{"a", 1, []byte{1}}, 1},
+		{[]interface{}{}, []interface{}{}, 0},
+	}
+
+	for _, test := range tests {
+		actual := c(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+	}
+}
+
+func TestSafe(t *testing.T) {
+
+	c := Safe(IntComparator)
+
+	if diff, err := c(1, 2); diff != -1 || err != nil {
+		t.Errorf("Got %v %v expected %v %v", diff, err, -1, nil)
+	}
+
+	_, err := c(1, "2")
+	if !errors.Is(err, ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, ErrKeyType)
+	}
+
+	var keyTypeErr *KeyTypeError
+	if !errors.As(err, &keyTypeErr) || keyTypeErr.Cause == nil {
+		t.Errorf("Got %#v expected a *KeyTypeError with its cause", err)
+	}
+
+	// keys IPComparator and TupleComparator reject themselves
+	if _, err := Safe(IPComparator)("not-an-ip", "not-an-ip"); !errors.Is(err, ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, ErrKeyType)
+	}
+	if _, err := Safe(TupleComparator(IntComparator))([]interface{}{1, 2}, []interface{}{1}); !errors.Is(err, ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, ErrKeyType)
+	}
+
+	// panics other than type assertions go on
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected the panic of the comparator")
+		}
+	}()
+	Safe(func(a, b interface{}) int { panic("boom") })(1, 1)
+}
+
+func TestCheckKey(t *testing.T) {
+
+	if err := CheckKey(StringComparator, "a"); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+
+	err := CheckKey(StringComparator, 1)
+	if !errors.Is(err, ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, ErrKeyType)
+	}
+
+	if actual, expected := err.Error(), "comparator: unexpected key type int: interface conversion: interface {} is int, not string"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+}
+
+func TestAuto(t *testing.T) {
+
+	now := time.Now()
+
+	// a,b,expected
+	tests := [][]interface{}{
+		{nil, nil, 0},
+		{nil, false, -1},
+		{false, true, -1},
+		{true, true, 0},
+		{true, math.Inf(-1), -1},
+		{1, int64(1), 0},
+		{1, 1.0, 0},
+		{uint8(1), json.Number("1"), 0},
+		{json.Number("1.5"), 1, 1},
+		{-1, uint(0), -1},
+		{uint64(math.MaxUint64), int64(math.MaxInt64), 1},
+		{json.Number("18446744073709551615"), uint64(math.MaxUint64), 0},
+		{int64(math.MaxInt64), float64(math.MaxInt64), -1}, // float64 rounds up to 2^63
+		{int64(1<<53 + 1), float64(1 << 53), 1},
+		{uint64(1<<53 + 1), float64(1 << 53), 1},
+		{-0.5, 0, -1},
+		{-0.5, uint(0), -1},
+		{math.NaN(), math.Inf(-1), -1},
+		{math.NaN(), int64(math.MinInt64), -1},
+		{float32(2.5), json.Number("2.5"), 0},
+		{json.Number("1e400"), uint64(math.MaxUint64), 1},
+		{1e300, "", -1},
+		{"a", "b", -1},
+		{"b", "ab", 1},
+		{"z", now, -1},
+		{now, now.Add(time.Nanosecond), -1},
+		{now.Add(time.Second), now, 1},
+	}
+
+	for _, test := range tests {
+		actual := Auto(test[0], test[1])
+		expected := test[2]
+		if actual != expected {
+			t.Errorf("Got %v expected %v for %v", actual, expected, test)
+		}
+		if actual, expected := Auto(test[1], test[0]), -expected.(int); actual != expected {
+			t.Errorf("Got %v expected %v for reversed %v", actual, expected, test)
+		}
+	}
+
+	values := []interface{}{"b", 2.5, json.Number("-3"), true, now, nil, uint(7), "a", false, int8(2)}
+	sort.Slice(values, func(i, j int) bool { return Auto(values[i], values[j]) < 0 })
+
+	if actual, expected := fmt.Sprint(values[:9]), "[<nil> false true -3 2 2.5 7 a b]"; actual != expected {
+		t.Errorf("Got %v expected %v", actual, expected)
+	}
+
+	// unsupported keys are reported by Safe
+	for _, key := range []interface{}{[]int{1}, json.Number("x"), struct{}{}} {
+		if _, err := Safe(Auto)(key, 1); !errors.Is(err, ErrKeyType) {
+			t.Errorf("Got %v expected %v for %v", err, ErrKeyType, key)
+		}
+	}
+}
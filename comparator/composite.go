@@ -0,0 +1,92 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-09 14:12:30
+ * @Description  : composite.go provides comparison on byte slices, addresses, big numbers and tuples
+ */
+package comparator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// BytesComparator provides a lexicographical comparison on []byte
+func BytesComparator(a, b interface{}) int {
+	return bytes.Compare(a.([]byte), b.([]byte))
+}
+
+// IPComparator provides a comparison on IP addresses of type net.IP or netip.Addr,
+// IPv4 addresses come before IPv6 ones and each are ordered numerically.
+// The 4 and 16 bytes (IPv4-mapped) forms of an IPv4 address are equal.
+func IPComparator(a, b interface{}) int {
+	return toAddr(a).Compare(toAddr(b))
+}
+
+var (
+	errInvalidIP = errors.New("invalid IP")
+	errNotIP     = errors.New("not an IP address")
+)
+
+func toAddr(value interface{}) netip.Addr {
+	switch ip := value.(type) {
+	case netip.Addr:
+		return ip.Unmap()
+	case net.IP:
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			panic(&KeyTypeError{Key: value, Cause: fmt.Errorf("%w %v", errInvalidIP, []byte(ip))})
+		}
+		return addr.Unmap()
+	default:
+		panic(&KeyTypeError{Key: value, Cause: errNotIP})
+	}
+}
+
+// BigIntComparator provides a basic comparison on *big.Int
+func BigIntComparator(a, b interface{}) int {
+	return a.(*big.Int).Cmp(b.(*big.Int))
+}
+
+// DurationComparator provides a basic comparison on time.Duration
+func DurationComparator(a, b interface{}) int {
+	aAsserted := a.(time.Duration)
+	bAsserted := b.(time.Duration)
+
+	switch {
+	case aAsserted > bAsserted:
+		return 1
+	case aAsserted < bAsserted:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// TupleComparator returns a comparator on []interface{} comparing position i by comparators[i],
+// the first position that differs decides, and a tuple which is a prefix of the other comes first.
+// Tuples should not be longer than comparators, otherwise the comparator panics with a *KeyTypeError.
+func TupleComparator(comparators ...Comparator) Comparator {
+	return func(a, b interface{}) int {
+		t1 := a.([]interface{})
+		t2 := b.([]interface{})
+
+		for _, tuple := range [...][]interface{}{t1, t2} {
+			if len(tuple) > len(comparators) {
+				panic(&KeyTypeError{Key: tuple, Cause: fmt.Errorf("tuple of %d elements for %d comparators", len(tuple), len(comparators))})
+			}
+		}
+
+		for i := 0; i < len(t1) && i < len(t2); i++ {
+			if diff := comparators[i](t1[i], t2[i]); diff != 0 {
+				return diff
+			}
+		}
+
+		return compareInt(len(t1), len(t2))
+	}
+}
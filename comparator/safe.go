@@ -0,0 +1,78 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-11 09:52:17
+ * @Description  : safe.go turns type assertion panics of comparators into errors
+ */
+package comparator
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// ErrKeyType is matched by errors.Is for every *KeyTypeError
+var ErrKeyType = errors.New("comparator: unexpected key type")
+
+// KeyTypeError is returned when a key is not of the type a comparator asserts
+type KeyTypeError struct {
+	Key   interface{} // the offending key, nil if the failing side is unknown
+	Cause error       // the recovered type assertion error
+}
+
+func (e *KeyTypeError) Error() string {
+	if e.Key == nil {
+		return fmt.Sprintf("%v: %v", ErrKeyType, e.Cause)
+	}
+
+	return fmt.Sprintf("%v %T: %v", ErrKeyType, e.Key, e.Cause)
+}
+
+// Unwrap makes errors.Is(err, ErrKeyType) true
+func (e *KeyTypeError) Unwrap() error {
+	return ErrKeyType
+}
+
+// SafeComparator is a comparator reporting keys of unexpected type as *KeyTypeError instead of panicking
+type SafeComparator func(a, b interface{}) (int, error)
+
+// Safe returns a SafeComparator running c, type assertion panics of c become *KeyTypeError,
+// a *KeyTypeError panicked by c is returned as is, while any other panic goes on.
+func Safe(c Comparator) SafeComparator {
+	return func(a, b interface{}) (diff int, err error) {
+		defer recoverKeyType(&err, nil)
+
+		return c(a, b), nil
+	}
+}
+
+// CheckKey tells whether key is of the type compare asserts by comparing key to itself,
+// it returns a *KeyTypeError if compare panics on a type assertion.
+// Trees use it to validate a key before it meets the keys already stored.
+func CheckKey[T any](compare func(a, b T) int, key T) (err error) {
+	defer recoverKeyType(&err, key)
+
+	compare(key, key)
+
+	return nil
+}
+
+func recoverKeyType(err *error, key interface{}) {
+
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	switch cause := recovered.(type) {
+	case *runtime.TypeAssertionError:
+		*err = &KeyTypeError{Key: key, Cause: cause}
+		return
+	case *KeyTypeError:
+		// raised by comparators checking types themselves, e.g. Auto
+		*err = cause
+		return
+	}
+
+	panic(recovered)
+}
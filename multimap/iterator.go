@@ -0,0 +1,101 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-14 11:27:50
+ * @Description  :
+ */
+package multimap
+
+import "github.com/Jayj1997/go-common/rbt"
+
+// Iterator holding the iterator's state over the key-value pairs of a MultiMap,
+// pairs come in ascending key order and the values of a key in insertion order.
+type Iterator[K, V any] struct {
+	keys  rbt.Iterator[K, []V]
+	index int
+}
+
+// Iterator returns a stateful iterator whose elements are key/value pairs.
+func (m *MultiMap[K, V]) Iterator() Iterator[K, V] {
+	return Iterator[K, V]{keys: m.tree.Iterator(), index: -1}
+}
+
+// Next moves the iterator to the next pair and returns true if there was a next pair in the container.
+// If Next() returns true, then next pair's key and value can be retrieved by Key() and Value().
+// If Next() was called for the first time, then it will point the iterator to the first pair if it exists.
+// Modifies the state of the iterator
+func (iterator *Iterator[K, V]) Next() bool {
+
+	if iterator.index >= 0 && iterator.index+1 < len(iterator.keys.Value()) {
+		iterator.index++
+		return true
+	}
+
+	if !iterator.keys.Next() {
+		iterator.index = -1
+		return false
+	}
+
+	iterator.index = 0
+
+	return true
+}
+
+// Previous moves the iterator to the previous pair and returns true if there was a previous pair in the container.
+// If Previous() returns true, then previous pair's key and value can be retrieved by Key() and Value().
+// Modifies the state of the iterator
+func (iterator *Iterator[K, V]) Previous() bool {
+
+	if iterator.index > 0 {
+		iterator.index--
+		return true
+	}
+
+	if !iterator.keys.Previous() {
+		iterator.index = -1
+		return false
+	}
+
+	iterator.index = len(iterator.keys.Value()) - 1
+
+	return true
+}
+
+// Key returns the current pair's key
+// Does not modify the state of the iterator
+func (iterator *Iterator[K, V]) Key() K {
+	return iterator.keys.Key()
+}
+
+// Value returns the current pair's value
+// Does not modify the state of the iterator
+func (iterator *Iterator[K, V]) Value() V {
+	return iterator.keys.Value()[iterator.index]
+}
+
+// Begin resets the iterator to its initial state (one-before-first)
+// Call Next() to fetch the first pair if any.
+func (iterator *Iterator[K, V]) Begin() {
+	iterator.keys.Begin()
+	iterator.index = -1
+}
+
+// End moves the iterator past the last pair (one-past-the-end)
+// Call Previous() to fetch the last pair if any
+func (iterator *Iterator[K, V]) End() {
+	iterator.keys.End()
+	iterator.index = -1
+}
+
+// First moves the iterator to the first pair and returns true if there was a first pair in the container.
+// Modifies the state of the iterator
+func (iterator *Iterator[K, V]) First() bool {
+	iterator.Begin()
+	return iterator.Next()
+}
+
+// Last moves the iterator to the last pair and returns true if there was a last pair in the container.
+// Modifies the state of the iterator
+func (iterator *Iterator[K, V]) Last() bool {
+	iterator.End()
+	return iterator.Previous()
+}
@@ -0,0 +1,231 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-14 10:08:36
+ * @Description  : sorted multimap backed by a red-black tree
+ */
+package multimap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Jayj1997/go-common/comparator"
+	"github.com/Jayj1997/go-common/rbt"
+)
+
+// MultiMap associates a key with any number of values, keys are kept in a red-black tree
+// and the values of a key in insertion order, so pairs iterate in ascending key order.
+// A key is in the map as long as it holds at least one value.
+type MultiMap[K, V any] struct {
+	tree *rbt.Tree[K, []V]
+	size int // number of key-value pairs
+}
+
+// New instantiates a multimap over an ordered key type
+func New[K cmp.Ordered, V any]() *MultiMap[K, V] {
+	return &MultiMap[K, V]{tree: rbt.New[K, []V]()}
+}
+
+// NewWithFunc instantiates a multimap with a typed compare function
+func NewWithFunc[K, V any](compare func(a, b K) int) *MultiMap[K, V] {
+	return &MultiMap[K, V]{tree: rbt.NewWithFunc[K, []V](compare)}
+}
+
+// NewWith instantiates a multimap with the custom comparator,
+// i.e. keys and values are of type interface{}.
+func NewWith(comparator comparator.Comparator) *MultiMap[interface{}, interface{}] {
+	return NewWithFunc[interface{}, interface{}](comparator)
+}
+
+// NewWithIntComparator instantiates a multimap with IntComparator, i.e. keys are of type int.
+func NewWithIntComparator() *MultiMap[interface{}, interface{}] {
+	return NewWith(comparator.IntComparator)
+}
+
+// NewWithStringComparator instantiates a multimap with StringComparator, i.e. keys are of type string.
+func NewWithStringComparator() *MultiMap[interface{}, interface{}] {
+	return NewWith(comparator.StringComparator)
+}
+
+// Put appends the values to the values of key, the same value may be put many times
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) Put(key K, values ...V) {
+
+	if len(values) == 0 {
+		return
+	}
+
+	current, _ := m.tree.Get(key)
+	m.tree.Insert(key, append(current, values...))
+	m.size += len(values)
+}
+
+// GetAll returns a copy of the values of key in insertion order, nil if key is not found
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) GetAll(key K) []V {
+	values, _ := m.tree.Get(key)
+	return slices.Clone(values)
+}
+
+// Contains returns true if key holds any value
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) Contains(key K) bool {
+	_, found := m.tree.Get(key)
+	return found
+}
+
+// ContainsValue returns true if key holds value, use MultiMap.ContainsValueFunc for values which aren't comparable.
+// Key should adhere to the comparator's type assertion, otherwise function panics
+func ContainsValue[K any, V comparable](m *MultiMap[K, V], key K, value V) bool {
+	return m.ContainsValueFunc(key, value, equal[V])
+}
+
+// ContainsValueFunc returns true if key holds a value equal to value as told by equal
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) ContainsValueFunc(key K, value V, equal func(a, b V) bool) bool {
+	values, _ := m.tree.Get(key)
+	return index(values, value, equal) >= 0
+}
+
+// RemoveValue removes the first occurrence of value from the values of key,
+// key is removed along with its last value, returns true if a value was removed.
+// Use MultiMap.RemoveValueFunc for values which aren't comparable.
+func RemoveValue[K any, V comparable](m *MultiMap[K, V], key K, value V) bool {
+	return m.RemoveValueFunc(key, value, equal[V])
+}
+
+// RemoveValueFunc removes the first value of key equal to value as told by equal,
+// key is removed along with its last value, returns true if a value was removed.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) RemoveValueFunc(key K, value V, equal func(a, b V) bool) bool {
+
+	values, _ := m.tree.Get(key)
+
+	i := index(values, value, equal)
+	if i < 0 {
+		return false
+	}
+
+	if len(values) == 1 {
+		m.tree.Remove(key)
+	} else {
+		m.tree.Insert(key, slices.Delete(values, i, i+1))
+	}
+
+	m.size--
+
+	return true
+}
+
+// RemoveAll removes key and returns its values in insertion order, nil if key is not found
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) RemoveAll(key K) []V {
+
+	values, found := m.tree.Get(key)
+	if !found {
+		return nil
+	}
+
+	m.tree.Remove(key)
+	m.size -= len(values)
+
+	return values
+}
+
+// Count returns the number of values of key
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) Count(key K) int {
+	values, _ := m.tree.Get(key)
+	return len(values)
+}
+
+// Size returns the number of key-value pairs
+func (m *MultiMap[K, V]) Size() int {
+	return m.size
+}
+
+// KeyCount returns the number of distinct keys
+func (m *MultiMap[K, V]) KeyCount() int {
+	return m.tree.Size()
+}
+
+// Empty returns true if the multimap does not contain any pairs
+func (m *MultiMap[K, V]) Empty() bool {
+	return m.size == 0
+}
+
+// Keys returns the distinct keys in-order
+func (m *MultiMap[K, V]) Keys() []K {
+	return m.tree.Keys()
+}
+
+// Values returns the values of all pairs in-order based on the key
+func (m *MultiMap[K, V]) Values() []V {
+
+	values := make([]V, 0, m.size)
+
+	m.tree.Each(func(_ K, current []V) bool {
+		values = append(values, current...)
+		return true
+	})
+
+	return values
+}
+
+// Clear removes all pairs
+func (m *MultiMap[K, V]) Clear() {
+	m.tree.Clear()
+	m.size = 0
+}
+
+// Each calls fn on every key-value pair in ascending key order,
+// the values of a key in insertion order, until fn returns false.
+func (m *MultiMap[K, V]) Each(fn func(key K, value V) bool) {
+	m.tree.Each(func(key K, values []V) bool {
+		for _, value := range values {
+			if !fn(key, value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Range calls fn on every key-value pair whose key lies between lo and hi in ascending key order,
+// loInclusive and hiInclusive decide whether lo and hi themselves are included.
+// Iteration stops early once fn returns false.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *MultiMap[K, V]) Range(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	m.tree.Range(lo, hi, loInclusive, hiInclusive, func(key K, values []V) bool {
+		for _, value := range values {
+			if !fn(key, value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// String returns a string representation of container
+func (m *MultiMap[K, V]) String() string {
+	elements := make([]string, 0, m.tree.Size())
+
+	m.tree.Each(func(key K, values []V) bool {
+		elements = append(elements, fmt.Sprintf("%v:%v", key, values))
+		return true
+	})
+
+	return "MultiMap\nmap[" + strings.Join(elements, " ") + "]"
+}
+
+func index[V any](values []V, value V, equal func(a, b V) bool) int {
+	return slices.IndexFunc(values, func(current V) bool {
+		return equal(current, value)
+	})
+}
+
+func equal[V comparable](a, b V) bool {
+	return a == b
+}
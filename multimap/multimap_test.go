@@ -0,0 +1,161 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-14 11:52:06
+ * @Description  :
+ */
+package multimap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+func TestMultiMap(t *testing.T) {
+
+	m := NewWithIntComparator()
+
+	m.Put(2, "b")
+	m.Put(1, "a", "x")
+	m.Put(2, "b", "c")
+	m.Put(3)
+
+	if actualValue, expectedValue := fmt.Sprint(m.Size(), m.KeyCount(), m.Count(1), m.Count(2), m.Count(3)), "5 2 2 3 0"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(m.Keys(), m.Values()), "[1 2] [a x b b c]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(m.GetAll(2), m.GetAll(3) == nil), "[b b c] true"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// GetAll returns a copy
+	m.GetAll(2)[0] = "z"
+
+	if actualValue, expectedValue := fmt.Sprint(m.Contains(2), m.Contains(3), ContainsValue(m, 2, "c"), ContainsValue(m, 2, "z")), "true false true false"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if actualValue, expectedValue := m.String(), "MultiMap\nmap[1:[a x] 2:[b b c]]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestMultiMapRemove(t *testing.T) {
+
+	m := New[string, int]()
+	m.Put("a", 1, 2, 1)
+	m.Put("b", 3)
+
+	tests := []struct {
+		key      string
+		value    int
+		removed  bool
+		expected string
+	}{
+		{"a", 1, true, "map[a:[2 1] b:[3]]"},
+		{"a", 4, false, "map[a:[2 1] b:[3]]"},
+		{"c", 1, false, "map[a:[2 1] b:[3]]"},
+		{"b", 3, true, "map[a:[2 1]]"},
+		{"a", 1, true, "map[a:[2]]"},
+	}
+
+	for _, test := range tests {
+		if actualValue := RemoveValue(m, test.key, test.value); actualValue != test.removed {
+			t.Errorf("Got %v expected %v for %v", actualValue, test.removed, test)
+		}
+		if actualValue, expectedValue := m.String(), "MultiMap\n"+test.expected; actualValue != expectedValue {
+			t.Errorf("Got %v expected %v", actualValue, expectedValue)
+		}
+	}
+
+	// values which aren't comparable
+	lists := New[string, []int]()
+	lists.Put("a", []int{1}, []int{1, 2})
+	equal := func(a, b []int) bool {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(lists.ContainsValueFunc("a", []int{1, 2}, equal), lists.ContainsValueFunc("a", []int{2}, equal)), "true false"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(lists.RemoveValueFunc("a", []int{1}, equal), lists.GetAll("a")), "true [[1 2]]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	m.Put("c", 5, 6)
+
+	if actualValue, expectedValue := fmt.Sprint(m.RemoveAll("c"), m.RemoveAll("d") == nil, m.Size(), m.KeyCount()), "[5 6] true 1 1"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	m.Clear()
+
+	if actualValue := m.Empty(); !actualValue || m.Size() != 0 || m.KeyCount() != 0 {
+		t.Errorf("Got %v expected %v", actualValue, true)
+	}
+}
+
+func TestMultiMapIteration(t *testing.T) {
+
+	// timestamp -> events
+	start := time.Date(2021, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	m := NewWith(comparator.TimeComparator)
+	m.Put(start.Add(2*time.Minute), "deploy")
+	m.Put(start, "start", "login")
+	m.Put(start.Add(time.Minute), "query")
+	m.Put(start.Add(2*time.Minute), "restart")
+
+	var pairs []string
+
+	m.Each(func(key, value interface{}) bool {
+		pairs = append(pairs, fmt.Sprintf("%v:%v", key.(time.Time).Minute(), value))
+		return value != "deploy"
+	})
+
+	if actualValue, expectedValue := fmt.Sprint(pairs), "[0:start 0:login 1:query 2:deploy]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	var events []interface{}
+
+	m.Range(start.Add(time.Second), start.Add(2*time.Minute), true, true, func(key, value interface{}) bool {
+		events = append(events, value)
+		return true
+	})
+
+	if actualValue, expectedValue := fmt.Sprint(events), "[query deploy restart]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	it := m.Iterator()
+	forward, backward := []interface{}{}, []interface{}{}
+
+	for it.Next() {
+		forward = append(forward, it.Value())
+	}
+
+	for it.Previous() {
+		backward = append(backward, it.Value())
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(forward, backward), "[start login query deploy restart] [restart deploy query login start]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	if !it.Last() || it.Value() != "restart" || !it.First() || it.Value() != "start" || !it.Next() || it.Value() != "login" {
+		t.Errorf("Got %v expected %v", it.Value(), "login")
+	}
+
+	empty := New[int, int]().Iterator()
+	if empty.Next() || empty.Previous() || empty.First() || empty.Last() {
+		t.Errorf("Got pairs from an empty multimap")
+	}
+}
@@ -0,0 +1,50 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-11 10:26:03
+ * @Description  : error-returning variants of Insert/Get/Remove validating the key type
+ */
+package rbt
+
+import "github.com/Jayj1997/go-common/comparator"
+
+// CheckKey returns a *comparator.KeyTypeError, matched by errors.Is(err, comparator.ErrKeyType),
+// if key doesn't adhere to the comparator's type assertion, nil otherwise.
+// Keys already in the tree are valid, so the comparator is run on key alone.
+func (tree *Tree[K, V]) CheckKey(key K) error {
+	return comparator.CheckKey(tree.Comparator, key)
+}
+
+// TryInsert is Insert returning a *comparator.KeyTypeError instead of panicking on a mistyped key,
+// the tree is left untouched in that case.
+func (tree *Tree[K, V]) TryInsert(key K, value V) error {
+	if err := tree.CheckKey(key); err != nil {
+		return err
+	}
+
+	tree.Insert(key, value)
+
+	return nil
+}
+
+// TryGet is Get returning a *comparator.KeyTypeError instead of panicking on a mistyped key
+func (tree *Tree[K, V]) TryGet(key K) (value V, found bool, err error) {
+	if err = tree.CheckKey(key); err != nil {
+		return value, false, err
+	}
+
+	value, found = tree.Get(key)
+
+	return value, found, nil
+}
+
+// TryRemove is Remove returning a *comparator.KeyTypeError instead of panicking on a mistyped key,
+// the tree is left untouched in that case.
+func (tree *Tree[K, V]) TryRemove(key K) error {
+	if err := tree.CheckKey(key); err != nil {
+		return err
+	}
+
+	tree.Remove(key)
+
+	return nil
+}
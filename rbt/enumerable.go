@@ -0,0 +1,97 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-29 10:34:18
+ * @Description  : functional helpers over the elements in key order
+ */
+package rbt
+
+// Each calls fn on every element in ascending key order, iteration stops when fn returns false
+func (tree *Tree[K, V]) Each(fn func(key K, value V) bool) {
+	for it := tree.Iterator(); it.Next(); {
+		if !fn(it.Key(), it.Value()) {
+			return
+		}
+	}
+}
+
+// Map returns a new tree with the same comparator holding the key-value pairs returned by fn for every element,
+// a key returned more than once keeps the value of the last (in key order of the source) element.
+func (tree *Tree[K, V]) Map(fn func(key K, value V) (K, V)) *Tree[K, V] {
+
+	mapped := tree.empty()
+
+	tree.Each(func(key K, value V) bool {
+		mapped.Insert(fn(key, value))
+		return true
+	})
+
+	return mapped
+}
+
+// Filter returns a new tree with the same comparator holding the elements for which fn returns true,
+// it's the select of other collections, Select is the order statistic of the tree.
+func (tree *Tree[K, V]) Filter(fn func(key K, value V) bool) *Tree[K, V] {
+
+	selected := tree.empty()
+
+	tree.Each(func(key K, value V) bool {
+		if fn(key, value) {
+			selected.Insert(key, value)
+		}
+		return true
+	})
+
+	return selected
+}
+
+// Any returns true if fn returns true for any element, it stops at the first one
+func (tree *Tree[K, V]) Any(fn func(key K, value V) bool) bool {
+	_, _, found := tree.Find(fn)
+	return found
+}
+
+// All returns true if fn returns true for all elements (or the tree is empty), it stops at the first false
+func (tree *Tree[K, V]) All(fn func(key K, value V) bool) bool {
+
+	all := true
+
+	tree.Each(func(key K, value V) bool {
+		all = fn(key, value)
+		return all
+	})
+
+	return all
+}
+
+// Find returns the first element in key order for which fn returns true,
+// third return parameter is false if there is none.
+func (tree *Tree[K, V]) Find(fn func(key K, value V) bool) (key K, value V, found bool) {
+
+	tree.Each(func(k K, v V) bool {
+		if fn(k, v) {
+			key, value, found = k, v, true
+		}
+		return !found
+	})
+
+	return key, value, found
+}
+
+// Reduce folds the elements in ascending key order into an accumulator starting from initial,
+// it's a function since methods can't introduce the accumulator's type parameter.
+func Reduce[K, V, A any](tree *Tree[K, V], initial A, fn func(accumulator A, key K, value V) A) A {
+
+	accumulator := initial
+
+	tree.Each(func(key K, value V) bool {
+		accumulator = fn(accumulator, key, value)
+		return true
+	})
+
+	return accumulator
+}
+
+// empty returns a new empty tree configured like tree
+func (tree *Tree[K, V]) empty() *Tree[K, V] {
+	return &Tree[K, V]{Comparator: tree.Comparator, KeyDecoder: tree.KeyDecoder}
+}
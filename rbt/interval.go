@@ -0,0 +1,251 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-15 09:44:18
+ * @Description  : interval tree on the red-black tree augmented with the max endpoint
+ */
+package rbt
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+// Interval is the closed interval [Low, High]
+type Interval[T any] struct {
+	Low  T
+	High T
+}
+
+// IntervalTree maps intervals to values and finds the intervals overlapping an interval or a point.
+// Intervals are kept in a red-black tree ordered by Low then High, every node also holds
+// the largest High of its subtree, which is maintained by insertion, removal and rotations,
+// so a query skips the subtrees ending before it and takes O((k+1)lgn) for k results.
+type IntervalTree[T, V any] struct {
+	tree    *Tree[Interval[T], intervalEntry[T, V]]
+	compare func(a, b T) int
+}
+
+// intervalEntry is the value of a node, max is the largest High in the subtree of the node
+type intervalEntry[T, V any] struct {
+	value V
+	max   T
+}
+
+// NewInterval instantiates an interval tree over an ordered endpoint type
+func NewInterval[T cmp.Ordered, V any]() *IntervalTree[T, V] {
+	return NewIntervalWithFunc[T, V](cmp.Compare[T])
+}
+
+// NewIntervalWithFunc instantiates an interval tree with a typed compare function for endpoints
+func NewIntervalWithFunc[T, V any](compare func(a, b T) int) *IntervalTree[T, V] {
+
+	intervals := &IntervalTree[T, V]{compare: compare}
+
+	intervals.tree = NewWithFunc[Interval[T], intervalEntry[T, V]](func(a, b Interval[T]) int {
+		if diff := compare(a.Low, b.Low); diff != 0 {
+			return diff
+		}
+		return compare(a.High, b.High)
+	})
+
+	intervals.tree.augment = intervals.augment
+
+	return intervals
+}
+
+// NewIntervalWith instantiates an interval tree with the custom comparator for endpoints,
+// e.g. comparator.TimeComparator for time slots, i.e. endpoints and values are of type interface{}.
+func NewIntervalWith(comparator comparator.Comparator) *IntervalTree[interface{}, interface{}] {
+	return NewIntervalWithFunc[interface{}, interface{}](comparator)
+}
+
+// Insert maps the interval [low, high] to value, overwriting the value of the same interval,
+// an interval given with low larger than high is stored as [high, low].
+// Endpoints should adhere to the comparator's type assertion, otherwise method panics
+func (intervals *IntervalTree[T, V]) Insert(low, high T, value V) {
+	interval := intervals.interval(low, high)
+	intervals.tree.Insert(interval, intervalEntry[T, V]{value: value, max: interval.High})
+}
+
+// Get returns the value of the interval [low, high],
+// second return parameter is true if the interval was found, otherwise false
+func (intervals *IntervalTree[T, V]) Get(low, high T) (value V, found bool) {
+	entry, found := intervals.tree.Get(intervals.interval(low, high))
+	return entry.value, found
+}
+
+// Delete removes the interval [low, high] and returns true if it was in the tree
+// Endpoints should adhere to the comparator's type assertion, otherwise method panics
+func (intervals *IntervalTree[T, V]) Delete(low, high T) bool {
+
+	interval := intervals.interval(low, high)
+
+	if intervals.tree.lookup(interval) == nil {
+		return false
+	}
+
+	intervals.tree.Remove(interval)
+
+	return true
+}
+
+// Overlapping returns the intervals sharing at least one point with [low, high], and their values,
+// in ascending order of the intervals.
+// Endpoints should adhere to the comparator's type assertion, otherwise method panics
+func (intervals *IntervalTree[T, V]) Overlapping(low, high T) ([]Interval[T], []V) {
+
+	var found []Interval[T]
+	var values []V
+
+	query := intervals.interval(low, high)
+
+	intervals.overlapping(intervals.tree.Root, query, func(node *Node[Interval[T], intervalEntry[T, V]]) {
+		found = append(found, node.Key)
+		values = append(values, node.Value.value)
+	})
+
+	return found, values
+}
+
+// Stabbing returns the intervals containing point, and their values, in ascending order of the intervals
+// Point should adhere to the comparator's type assertion, otherwise method panics
+func (intervals *IntervalTree[T, V]) Stabbing(point T) ([]Interval[T], []V) {
+	return intervals.Overlapping(point, point)
+}
+
+// Empty returns true if tree does not contain any intervals
+func (intervals *IntervalTree[T, V]) Empty() bool {
+	return intervals.tree.Empty()
+}
+
+// Size returns number of intervals in the tree
+func (intervals *IntervalTree[T, V]) Size() int {
+	return intervals.tree.Size()
+}
+
+// Intervals returns all intervals in ascending order
+func (intervals *IntervalTree[T, V]) Intervals() []Interval[T] {
+	return intervals.tree.Keys()
+}
+
+// Values returns all values in ascending order of their intervals
+func (intervals *IntervalTree[T, V]) Values() []V {
+
+	values := make([]V, 0, intervals.tree.Size())
+
+	intervals.tree.Each(func(_ Interval[T], entry intervalEntry[T, V]) bool {
+		values = append(values, entry.value)
+		return true
+	})
+
+	return values
+}
+
+// Each calls fn on every interval and its value in ascending order until fn returns false
+func (intervals *IntervalTree[T, V]) Each(fn func(interval Interval[T], value V) bool) {
+	intervals.tree.Each(func(interval Interval[T], entry intervalEntry[T, V]) bool {
+		return fn(interval, entry.value)
+	})
+}
+
+// Clear removes all intervals from the tree
+func (intervals *IntervalTree[T, V]) Clear() {
+	intervals.tree.Clear()
+}
+
+// Validate checks the red-black tree properties, see Tree.Validate,
+// and that every node holds the largest High of its subtree.
+// It takes O(n) and is meant for tests and debugging.
+func (intervals *IntervalTree[T, V]) Validate() error {
+
+	if err := intervals.tree.Validate(); err != nil {
+		return err
+	}
+
+	var check func(node *Node[Interval[T], intervalEntry[T, V]]) error
+
+	check = func(node *Node[Interval[T], intervalEntry[T, V]]) error {
+		if node == nil {
+			return nil
+		}
+
+		if err := check(node.Left); err != nil {
+			return err
+		}
+
+		if err := check(node.Right); err != nil {
+			return err
+		}
+
+		if max := intervals.max(node); intervals.compare(node.Value.max, max) != 0 {
+			return fmt.Errorf("%w: node %v holds max %v, its subtree ends at %v", ErrInvalid, node.Key, node.Value.max, max)
+		}
+
+		return nil
+	}
+
+	return check(intervals.tree.Root)
+}
+
+// String returns a string representation of container
+func (intervals *IntervalTree[T, V]) String() string {
+	str := "INTERVALTREE\n"
+
+	if !intervals.tree.Empty() {
+		output(intervals.tree.Root, "", true, &str)
+	}
+
+	return str
+}
+
+// interval builds [low, high] swapping the endpoints if needed
+func (intervals *IntervalTree[T, V]) interval(low, high T) Interval[T] {
+	if intervals.compare(low, high) > 0 {
+		low, high = high, low
+	}
+
+	return Interval[T]{Low: low, High: high}
+}
+
+// augment is the hook of the red-black tree keeping the max of node up to date
+func (intervals *IntervalTree[T, V]) augment(node *Node[Interval[T], intervalEntry[T, V]]) {
+	node.Value.max = intervals.max(node)
+}
+
+// max returns the largest High among node and the max of its children
+func (intervals *IntervalTree[T, V]) max(node *Node[Interval[T], intervalEntry[T, V]]) T {
+
+	max := node.Key.High
+
+	for _, child := range [...]*Node[Interval[T], intervalEntry[T, V]]{node.Left, node.Right} {
+		if child != nil && intervals.compare(child.Value.max, max) > 0 {
+			max = child.Value.max
+		}
+	}
+
+	return max
+}
+
+// overlapping visits the nodes of the subtree overlapping query in-order,
+// skipping subtrees which end before query or start after it
+func (intervals *IntervalTree[T, V]) overlapping(node *Node[Interval[T], intervalEntry[T, V]], query Interval[T], visit func(node *Node[Interval[T], intervalEntry[T, V]])) {
+
+	if node == nil || intervals.compare(node.Value.max, query.Low) < 0 {
+		return
+	}
+
+	intervals.overlapping(node.Left, query, visit)
+
+	// node and its right subtree start after query
+	if intervals.compare(node.Key.Low, query.High) > 0 {
+		return
+	}
+
+	if intervals.compare(node.Key.High, query.Low) >= 0 {
+		visit(node)
+	}
+
+	intervals.overlapping(node.Right, query, visit)
+}
@@ -5,9 +5,9 @@
  */
 package rbt
 
-type Iterator struct {
-	tree     *Tree
-	node     *Node
+type Iterator[K, V any] struct {
+	tree     *Tree[K, V]
+	node     *Node[K, V]
 	position position
 }
 
@@ -18,20 +18,101 @@ const (
 )
 
 // Iterator returns a stateful iterator whose elements are key/value pairs.
-func (tree *Tree) Iterator() Iterator {
-	return Iterator{tree: tree, node: nil, position: begin}
+func (tree *Tree[K, V]) Iterator() Iterator[K, V] {
+	return Iterator[K, V]{tree: tree, node: nil, position: begin}
 }
 
 // IteratorAt returns a stateful iterator whose elements are key/value pairs that is initialized at a particular node.
-func (tree *Tree) IteratorAt(node *Node) Iterator {
-	return Iterator{tree: tree, node: node, position: between}
+func (tree *Tree[K, V]) IteratorAt(node *Node[K, V]) Iterator[K, V] {
+	return Iterator[K, V]{tree: tree, node: node, position: between}
+}
+
+// IteratorFrom returns a stateful iterator positioned right before the ceiling of key,
+// so Next() fetches the smallest element whose key is larger than or equal to key.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) IteratorFrom(key K) Iterator[K, V] {
+	floor, found := tree.Floor(key)
+	if !found {
+		return tree.Iterator()
+	}
+
+	iterator := tree.IteratorAt(floor)
+	if tree.Comparator(floor.Key, key) == 0 {
+		// step back so the equal key is fetched by the first Next()
+		iterator.Previous()
+	}
+
+	return iterator
+}
+
+// iteratorBefore returns a stateful iterator positioned right after the floor of key,
+// so Previous() fetches the largest element whose key is smaller than or equal to key.
+func (tree *Tree[K, V]) iteratorBefore(key K) Iterator[K, V] {
+	ceiling, found := tree.Ceiling(key)
+	if !found {
+		iterator := tree.Iterator()
+		iterator.End()
+		return iterator
+	}
+
+	iterator := tree.IteratorAt(ceiling)
+	if tree.Comparator(ceiling.Key, key) == 0 {
+		iterator.Next()
+	}
+
+	return iterator
+}
+
+// Range calls fn on every element whose key lies between lo and hi in ascending order,
+// loInclusive and hiInclusive decide whether lo and hi themselves are included.
+// Iteration stops early once fn returns false.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) Range(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	iterator := tree.IteratorFrom(lo)
+
+	for iterator.Next() {
+		if !loInclusive && tree.Comparator(iterator.Key(), lo) == 0 {
+			continue
+		}
+
+		compare := tree.Comparator(iterator.Key(), hi)
+		if compare > 0 || (compare == 0 && !hiInclusive) {
+			return
+		}
+
+		if !fn(iterator.Key(), iterator.Value()) {
+			return
+		}
+	}
+}
+
+// ReverseRange is like Range but walks the elements from hi down to lo in descending order.
+// Iteration stops early once fn returns false.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) ReverseRange(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	iterator := tree.iteratorBefore(hi)
+
+	for iterator.Previous() {
+		if !hiInclusive && tree.Comparator(iterator.Key(), hi) == 0 {
+			continue
+		}
+
+		compare := tree.Comparator(iterator.Key(), lo)
+		if compare < 0 || (compare == 0 && !loInclusive) {
+			return
+		}
+
+		if !fn(iterator.Key(), iterator.Value()) {
+			return
+		}
+	}
 }
 
 // Next moves the iterator to the next element and returns true if there was a next element in the container.
 // if next() returns true, the next element's key and value can be retrieved by Key() and Value()
 // if next() was called for the first time, then it will point the iterator to the first element if it exists
 // Modifies the state of the iterator
-func (iterator *Iterator) Next() bool {
+func (iterator *Iterator[K, V]) Next() bool {
 
 	if iterator.position == end {
 		goto end
@@ -57,6 +138,16 @@ func (iterator *Iterator) Next() bool {
 		goto between
 	}
 
+	if iterator.tree.persistent {
+		// nodes are shared between versions so Parent can't be trusted,
+		// the nearest ancestor on the right is the next larger key in the tree
+		if iterator.node = iterator.tree.higher(iterator.node.Key); iterator.node != nil {
+			goto between
+		}
+
+		goto end
+	}
+
 	if iterator.node.Parent != nil {
 		node := iterator.node
 		for iterator.node.Parent != nil {
@@ -78,7 +169,7 @@ between:
 
 // Previous moves the iterator to the previous element and returns true if there was a previous element in the container.
 // if Previous() returns true, the previous element's key and value can be retrieved by Key() and Value()
-func (iterator *Iterator) Previous() bool {
+func (iterator *Iterator[K, V]) Previous() bool {
 
 	if iterator.position == begin {
 		goto begin
@@ -104,6 +195,15 @@ func (iterator *Iterator) Previous() bool {
 		goto between
 	}
 
+	if iterator.tree.persistent {
+		// the nearest ancestor on the left is the next smaller key in the tree
+		if iterator.node = iterator.tree.lower(iterator.node.Key); iterator.node != nil {
+			goto between
+		}
+
+		goto begin
+	}
+
 	if iterator.node.Parent != nil {
 		node := iterator.node
 
@@ -127,26 +227,26 @@ between:
 
 // Value returns the current element's value.
 // Dose not modify the state of the iterator
-func (iterator *Iterator) Value() interface{} {
+func (iterator *Iterator[K, V]) Value() V {
 	return iterator.node.Value
 }
 
 // Key returns the current element's key.
 // Does not modify the state of the iterator.
-func (iterator *Iterator) Key() interface{} {
+func (iterator *Iterator[K, V]) Key() K {
 	return iterator.node.Key
 }
 
 // Begin resets the iterator to its initial state (one-before-first)
 // Call Next() to fetch the first element if any
-func (iterator *Iterator) Begin() {
+func (iterator *Iterator[K, V]) Begin() {
 	iterator.node = nil
 	iterator.position = begin
 }
 
 // End moves the iterator past the last element (one-past-the-end).
 // Call Previous() to fetch the last element if any
-func (iterator *Iterator) End() {
+func (iterator *Iterator[K, V]) End() {
 	iterator.node = nil
 	iterator.position = end
 }
@@ -154,7 +254,7 @@ func (iterator *Iterator) End() {
 // First moves the iterator to the first element and returns true if there was a first element in the container.
 // If First() returns true, the first element's key and value can be retrieved by Key() and Value()
 // Modifies the state of the iterator
-func (iterator *Iterator) First() bool {
+func (iterator *Iterator[K, V]) First() bool {
 	iterator.Begin()
 	return iterator.Next()
 }
@@ -162,7 +262,7 @@ func (iterator *Iterator) First() bool {
 // Last moves the iterator to the last element and returns true if there was a last element in the container.
 // If Last() returns true, then last element's key and value can be retrieved by Key() and Value()
 // Modifies the state of the iterator
-func (iterator *Iterator) Last() bool {
+func (iterator *Iterator[K, V]) Last() bool {
 	iterator.End()
 	return iterator.Previous()
 }
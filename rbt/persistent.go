@@ -0,0 +1,361 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-16 10:21:07
+ * @Description  : persistent (copy-on-write) mode of red-black tree
+ */
+package rbt
+
+import "fmt"
+
+// In persistent mode nodes are never modified once they are built,
+// Insert and Remove copy the path from root to the touched node and return a new root,
+// all untouched sub-trees are shared between the old and the new version.
+// Parent is not maintained in this mode since a shared node has more than one parent,
+// iterators look ancestors up from the root instead.
+//
+// ref: Stefan Kahrs, Red-black trees with types, Journal of Functional Programming 11(4)
+
+// Persistent switches the tree into persistent mode and returns the tree itself.
+// Nodes already in the tree are taken over as they are.
+func (tree *Tree[K, V]) Persistent() *Tree[K, V] {
+	tree.persistent = true
+	return tree
+}
+
+// IsPersistent returns true if the tree is in persistent mode
+func (tree *Tree[K, V]) IsPersistent() bool {
+	return tree.persistent
+}
+
+// Snapshot is a read-only view of a tree at the moment Snapshot() was called,
+// it is not affected by any later Insert/Remove on the tree.
+type Snapshot[K, V any] struct {
+	tree Tree[K, V]
+}
+
+// Snapshot returns a read-only view of the current version of the tree in O(1),
+// the tree is switched into persistent mode so that later changes don't touch the nodes the snapshot sees.
+//
+// The tree stays in persistent mode for good, even once no snapshot is alive anymore:
+// Insert and Remove copy their path and every iterator step costs O(lgn) instead of amortized O(1).
+// Snapshot writes to the tree like Insert does, so it must not run concurrently with any other method,
+// use SyncTree.Snapshot to take snapshots of a tree shared between goroutines.
+func (tree *Tree[K, V]) Snapshot() *Snapshot[K, V] {
+	tree.persistent = true
+
+	return tree.snapshot()
+}
+
+// snapshot captures the current version of a tree already in persistent mode
+func (tree *Tree[K, V]) snapshot() *Snapshot[K, V] {
+	return &Snapshot[K, V]{tree: Tree[K, V]{Root: tree.Root, size: tree.size, Comparator: tree.Comparator, persistent: true}}
+}
+
+// Get searches the node in the snapshot by key and returns its value or nil if key is not found,
+// Second return parameter is true if key was found, otherwise false
+func (snapshot *Snapshot[K, V]) Get(key K) (value V, found bool) {
+	return snapshot.tree.Get(key)
+}
+
+// Empty returns true if snapshot does not contain any nodes
+func (snapshot *Snapshot[K, V]) Empty() bool {
+	return snapshot.tree.Empty()
+}
+
+// Size returns number of nodes in the snapshot
+func (snapshot *Snapshot[K, V]) Size() int {
+	return snapshot.tree.Size()
+}
+
+// Keys returns all keys in-order
+func (snapshot *Snapshot[K, V]) Keys() []K {
+	return snapshot.tree.Keys()
+}
+
+// Values returns all values in-order based on the key.
+func (snapshot *Snapshot[K, V]) Values() []V {
+	return snapshot.tree.Values()
+}
+
+// Left returns the left-most (min) node or nil if snapshot is empty
+func (snapshot *Snapshot[K, V]) Left() *Node[K, V] {
+	return snapshot.tree.Left()
+}
+
+// Right returns the right-most (max) node or nil if snapshot is empty
+func (snapshot *Snapshot[K, V]) Right() *Node[K, V] {
+	return snapshot.tree.Right()
+}
+
+// Floor finds floor node of the input key, see Tree.Floor
+func (snapshot *Snapshot[K, V]) Floor(key K) (floor *Node[K, V], found bool) {
+	return snapshot.tree.Floor(key)
+}
+
+// Ceiling finds ceiling node of the input key, see Tree.Ceiling
+func (snapshot *Snapshot[K, V]) Ceiling(key K) (ceiling *Node[K, V], found bool) {
+	return snapshot.tree.Ceiling(key)
+}
+
+// Rank returns the number of keys strictly smaller than key, see Tree.Rank
+func (snapshot *Snapshot[K, V]) Rank(key K) int {
+	return snapshot.tree.Rank(key)
+}
+
+// Select returns the node holding the k-th smallest key, see Tree.Select
+func (snapshot *Snapshot[K, V]) Select(k int) (node *Node[K, V], found bool) {
+	return snapshot.tree.Select(k)
+}
+
+// CountRange returns the number of keys in range [lo, hi), see Tree.CountRange
+func (snapshot *Snapshot[K, V]) CountRange(lo, hi K) int {
+	return snapshot.tree.CountRange(lo, hi)
+}
+
+// Iterator returns a stateful iterator over the snapshot,
+// which stays valid while the tree the snapshot was taken from keeps changing.
+func (snapshot *Snapshot[K, V]) Iterator() Iterator[K, V] {
+	return snapshot.tree.Iterator()
+}
+
+// IteratorFrom returns a stateful iterator over the snapshot positioned right before the ceiling of key, see Tree.IteratorFrom
+func (snapshot *Snapshot[K, V]) IteratorFrom(key K) Iterator[K, V] {
+	return snapshot.tree.IteratorFrom(key)
+}
+
+// Range calls fn on every element between lo and hi in ascending order, see Tree.Range
+func (snapshot *Snapshot[K, V]) Range(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	snapshot.tree.Range(lo, hi, loInclusive, hiInclusive, fn)
+}
+
+// ReverseRange calls fn on every element between hi and lo in descending order, see Tree.ReverseRange
+func (snapshot *Snapshot[K, V]) ReverseRange(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	snapshot.tree.ReverseRange(lo, hi, loInclusive, hiInclusive, fn)
+}
+
+// String returns a string representation of the snapshot
+func (snapshot *Snapshot[K, V]) String() string {
+	return snapshot.tree.String()
+}
+
+/** inner function related */
+
+func (tree *Tree[K, V]) insertPersistent(key K, value V) {
+	root, inserted := tree.insertPath(tree.Root, key, value)
+	tree.Root = paint(root, black)
+
+	if inserted {
+		tree.size++
+	}
+}
+
+// insertPath returns the new root of the sub-tree after inserting key,
+// second return parameter is false if an existing key was overwritten
+func (tree *Tree[K, V]) insertPath(node *Node[K, V], key K, value V) (*Node[K, V], bool) {
+	if node == nil {
+		return makeNode(red, nil, key, value, nil), true
+	}
+
+	compare := tree.Comparator(key, node.Key)
+	switch {
+	case compare < 0:
+		left, inserted := tree.insertPath(node.Left, key, value)
+		if node.color == black {
+			return balance(left, node.Key, node.Value, node.Right), inserted
+		}
+
+		return makeNode(red, left, node.Key, node.Value, node.Right), inserted
+	case compare > 0:
+		right, inserted := tree.insertPath(node.Right, key, value)
+		if node.color == black {
+			return balance(node.Left, node.Key, node.Value, right), inserted
+		}
+
+		return makeNode(red, node.Left, node.Key, node.Value, right), inserted
+	default:
+		// overwrite
+		return makeNode(node.color, node.Left, key, value, node.Right), false
+	}
+}
+
+func (tree *Tree[K, V]) removePersistent(key K) {
+	if tree.lookup(key) == nil {
+		return
+	}
+
+	tree.Root = paint(tree.removePath(tree.Root, key), black)
+	tree.size--
+}
+
+// removePath returns the new root of the sub-tree after removing key,
+// which must exist in the sub-tree
+func (tree *Tree[K, V]) removePath(node *Node[K, V], key K) *Node[K, V] {
+	if node == nil {
+		return nil
+	}
+
+	compare := tree.Comparator(key, node.Key)
+	switch {
+	case compare < 0:
+		if node.Left != nil && node.Left.color == black {
+			return balanceLeft(tree.removePath(node.Left, key), node.Key, node.Value, node.Right)
+		}
+
+		return makeNode(red, tree.removePath(node.Left, key), node.Key, node.Value, node.Right)
+	case compare > 0:
+		if node.Right != nil && node.Right.color == black {
+			return balanceRight(node.Left, node.Key, node.Value, tree.removePath(node.Right, key))
+		}
+
+		return makeNode(red, node.Left, node.Key, node.Value, tree.removePath(node.Right, key))
+	default:
+		return join(node.Left, node.Right)
+	}
+}
+
+// makeNode builds a new node, its size is derived from the children
+func makeNode[K, V any](c color, left *Node[K, V], key K, value V, right *Node[K, V]) *Node[K, V] {
+	return &Node[K, V]{Key: key, Value: value, color: c, Left: left, Right: right, size: nodeSize(left) + nodeSize(right) + 1}
+}
+
+// paint returns node in color c, node is copied if its color differs
+func paint[K, V any](node *Node[K, V], c color) *Node[K, V] {
+	if node == nil || node.color == c {
+		return node
+	}
+
+	return makeNode(c, node.Left, node.Key, node.Value, node.Right)
+}
+
+func isRed[K, V any](node *Node[K, V]) bool {
+	return nodeColor(node) == red
+}
+
+// balance builds a black node out of left, key and right,
+// resolving a red child with a red grandchild by making it a red node with two black children
+func balance[K, V any](left *Node[K, V], key K, value V, right *Node[K, V]) *Node[K, V] {
+	switch {
+	case isRed(left) && isRed(right):
+		return makeNode(red, paint(left, black), key, value, paint(right, black))
+	case isRed(left) && isRed(left.Left):
+		return makeNode(red, paint(left.Left, black), left.Key, left.Value,
+			makeNode(black, left.Right, key, value, right))
+	case isRed(left) && isRed(left.Right):
+		return makeNode(red, makeNode(black, left.Left, left.Key, left.Value, left.Right.Left), left.Right.Key, left.Right.Value,
+			makeNode(black, left.Right.Right, key, value, right))
+	case isRed(right) && isRed(right.Right):
+		return makeNode(red, makeNode(black, left, key, value, right.Left), right.Key, right.Value,
+			paint(right.Right, black))
+	case isRed(right) && isRed(right.Left):
+		return makeNode(red, makeNode(black, left, key, value, right.Left.Left), right.Left.Key, right.Left.Value,
+			makeNode(black, right.Left.Right, right.Key, right.Value, right.Right))
+	default:
+		return makeNode(black, left, key, value, right)
+	}
+}
+
+// balanceLeft restores the black height after the left sub-tree lost one black node
+func balanceLeft[K, V any](left *Node[K, V], key K, value V, right *Node[K, V]) *Node[K, V] {
+	switch {
+	case isRed(left):
+		return makeNode(red, paint(left, black), key, value, right)
+	case right != nil && right.color == black:
+		return balance(left, key, value, paint(right, red))
+	case isRed(right) && right.Left != nil && right.Left.color == black:
+		return makeNode(red, makeNode(black, left, key, value, right.Left.Left), right.Left.Key, right.Left.Value,
+			balance(right.Left.Right, right.Key, right.Value, redden(right.Right)))
+	default:
+		panic(fmt.Sprintf("rbt: invariant violated while rebalancing around %v", key))
+	}
+}
+
+// balanceRight restores the black height after the right sub-tree lost one black node
+func balanceRight[K, V any](left *Node[K, V], key K, value V, right *Node[K, V]) *Node[K, V] {
+	switch {
+	case isRed(right):
+		return makeNode(red, left, key, value, paint(right, black))
+	case left != nil && left.color == black:
+		return balance(paint(left, red), key, value, right)
+	case isRed(left) && left.Right != nil && left.Right.color == black:
+		return makeNode(red, balance(redden(left.Left), left.Key, left.Value, left.Right.Left), left.Right.Key, left.Right.Value,
+			makeNode(black, left.Right.Right, key, value, right))
+	default:
+		panic(fmt.Sprintf("rbt: invariant violated while rebalancing around %v", key))
+	}
+}
+
+// redden paints a black node red, the node must be black and not nil
+func redden[K, V any](node *Node[K, V]) *Node[K, V] {
+	if node == nil || node.color != black {
+		panic("rbt: invariant violated, expected a black node")
+	}
+
+	return paint(node, red)
+}
+
+// join concatenates two sub-trees of same black height whose keys are in order,
+// it's used to replace the removed node by its children
+func join[K, V any](left, right *Node[K, V]) *Node[K, V] {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case isRed(left) && isRed(right):
+		middle := join(left.Right, right.Left)
+		if isRed(middle) {
+			return makeNode(red, makeNode(red, left.Left, left.Key, left.Value, middle.Left), middle.Key, middle.Value,
+				makeNode(red, middle.Right, right.Key, right.Value, right.Right))
+		}
+
+		return makeNode(red, left.Left, left.Key, left.Value, makeNode(red, middle, right.Key, right.Value, right.Right))
+	case !isRed(left) && !isRed(right):
+		middle := join(left.Right, right.Left)
+		if isRed(middle) {
+			return makeNode(red, makeNode(black, left.Left, left.Key, left.Value, middle.Left), middle.Key, middle.Value,
+				makeNode(black, middle.Right, right.Key, right.Value, right.Right))
+		}
+
+		return balanceLeft(left.Left, left.Key, left.Value, makeNode(black, middle, right.Key, right.Value, right.Right))
+	case isRed(right):
+		return makeNode(red, join(left, right.Left), right.Key, right.Value, right.Right)
+	default:
+		return makeNode(red, left.Left, left.Key, left.Value, join(left.Right, right))
+	}
+}
+
+// higher returns the node with the smallest key strictly larger than key or nil
+func (tree *Tree[K, V]) higher(key K) *Node[K, V] {
+	var higher *Node[K, V]
+
+	node := tree.Root
+
+	for node != nil {
+		if tree.Comparator(key, node.Key) < 0 {
+			higher = node
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+
+	return higher
+}
+
+// lower returns the node with the largest key strictly smaller than key or nil
+func (tree *Tree[K, V]) lower(key K) *Node[K, V] {
+	var lower *Node[K, V]
+
+	node := tree.Root
+
+	for node != nil {
+		if tree.Comparator(key, node.Key) > 0 {
+			lower = node
+			node = node.Right
+		} else {
+			node = node.Left
+		}
+	}
+
+	return lower
+}
@@ -6,6 +6,7 @@
 package rbt
 
 import (
+	"cmp"
 	"fmt"
 
 	"github.com/Jayj1997/go-common/comparator"
@@ -30,51 +31,94 @@ const (
 )
 
 // tree holds elements of the red-black tree
-type Tree struct {
-	Root       *Node
+type Tree[K, V any] struct {
+	Root       *Node[K, V]
 	size       int
-	Comparator comparator.Comparator
+	Comparator func(a, b K) int
+	KeyDecoder func(data []byte) (K, error) // decodes JSON keys, see UnmarshalJSON
+	persistent bool                         // nodes are shared with snapshots and copied on write, see persistent.go
+	augment    func(node *Node[K, V])       // recomputes metadata of a node from its children, see interval.go
 }
 
-type Node struct {
-	Key    interface{}
-	Value  interface{}
+type Node[K, V any] struct {
+	Key    K
+	Value  V
 	color  color
-	Left   *Node
-	Right  *Node
-	Parent *Node
+	size   int // number of nodes in the subtree rooted at this node
+	Left   *Node[K, V]
+	Right  *Node[K, V]
+	Parent *Node[K, V]
+}
+
+// AnyTree, AnyNode and AnyIterator name the interface{} form built by NewWith and the comparator constructors.
+// Tree and Node became generic so the old names can't be kept,
+// code that named *rbt.Tree or *rbt.Node compiles again by renaming them to *rbt.AnyTree and *rbt.AnyNode.
+type (
+	AnyTree     = Tree[interface{}, interface{}]
+	AnyNode     = Node[interface{}, interface{}]
+	AnyIterator = Iterator[interface{}, interface{}]
+)
+
+// New instantiates a red-black tree over an ordered key type,
+// keys are compared with cmp.Compare so no boxing or type assertion is involved.
+func New[K cmp.Ordered, V any]() *Tree[K, V] {
+	return &Tree[K, V]{Comparator: cmp.Compare[K]}
 }
 
-// NewWith instantiates a red-black tree with the IntComparator,
-// i.e. keys are of type string.
-func NewWith(comparator comparator.Comparator) *Tree {
-	return &Tree{Comparator: comparator}
+// NewWithFunc instantiates a red-black tree with a typed compare function,
+// which should return negative, zero or positive like comparator.Comparator does.
+func NewWithFunc[K, V any](compare func(a, b K) int) *Tree[K, V] {
+	return &Tree[K, V]{Comparator: compare}
+}
+
+// NewWith instantiates a red-black tree with the custom comparator,
+// i.e. keys and values are of type interface{}.
+// It's the adapter for callers built on comparator.Comparator.
+func NewWith(comparator comparator.Comparator) *Tree[interface{}, interface{}] {
+	return &Tree[interface{}, interface{}]{Comparator: comparator}
 }
 
 // NewWithIntComparator instantiates a red-black tree with IntComparator,
 // i.e. keys are of type int.
-func NewWithIntComparator() *Tree {
-	return &Tree{Comparator: comparator.IntComparator}
+func NewWithIntComparator() *Tree[interface{}, interface{}] {
+	tree := NewWith(comparator.IntComparator)
+	tree.KeyDecoder = KeyDecoderOf[int]()
+	return tree
 }
 
 // NewWithStringComparator instantiates a red-black tree with the StringComparator,
 // i.e. keys are of type string.
-func NewWithStringComparator() *Tree {
-	return &Tree{Comparator: comparator.StringComparator}
+func NewWithStringComparator() *Tree[interface{}, interface{}] {
+	tree := NewWith(comparator.StringComparator)
+	tree.KeyDecoder = KeyDecoderOf[string]()
+	return tree
+}
+
+// NewWithAutoComparator instantiates a red-black tree with comparator.Auto,
+// i.e. keys are of mixed primitive types like those decoded from JSON.
+func NewWithAutoComparator() *Tree[interface{}, interface{}] {
+	tree := NewWith(comparator.Auto)
+	tree.KeyDecoder = AutoKeyDecoder()
+	return tree
 }
 
 /** function related */
 
 // Insert inserts node into the tree
 // Key should adhere to the comparator's type assertion, otherwise method panics
-func (tree *Tree) Insert(key, value interface{}) {
+func (tree *Tree[K, V]) Insert(key K, value V) {
+
+	if tree.persistent {
+		tree.insertPersistent(key, value)
+		return
+	}
 
-	var insertedNode *Node
+	var insertedNode *Node[K, V]
 
 	if tree.Root == nil {
 		// Assert key is of comparator's type for initial tree
 		tree.Comparator(key, key)
-		tree.Root = &Node{Key: key, Value: value, color: red}
+		tree.Root = &Node[K, V]{Key: key, Value: value, color: red, size: 1}
 		insertedNode = tree.Root
 	} else {
 		node := tree.Root
@@ -87,10 +131,11 @@ func (tree *Tree) Insert(key, value interface{}) {
 				// overwrite
 				node.Key = key
 				node.Value = value
+				tree.augmentPath(node)
 				return
 			case compare < 0:
 				if node.Left == nil {
-					node.Left = &Node{Key: key, Value: value, color: red}
+					node.Left = &Node[K, V]{Key: key, Value: value, color: red, size: 1}
 					insertedNode = node.Left
 					loop = false
 				} else {
@@ -98,7 +143,7 @@ func (tree *Tree) Insert(key, value interface{}) {
 				}
 			case compare > 0:
 				if node.Right == nil {
-					node.Right = &Node{Key: key, Value: value, color: red}
+					node.Right = &Node[K, V]{Key: key, Value: value, color: red, size: 1}
 					insertedNode = node.Right
 					loop = false
 				} else {
@@ -107,8 +152,13 @@ func (tree *Tree) Insert(key, value interface{}) {
 			}
 		}
 		insertedNode.Parent = node
+
+		for ; node != nil; node = node.Parent {
+			node.size++
+		}
 	}
 
+	tree.augmentPath(insertedNode)
 	tree.insertCase1(insertedNode)
 	tree.size++
 }
@@ -116,19 +166,24 @@ func (tree *Tree) Insert(key, value interface{}) {
 // Get searchs the node in the tree by key and returns its value or nil if key is not found in tree,
 // Second return parameter is true if key was found, otherwise false
 // Key should adhere to the comparator's type assertion, otherwise method panics
-func (tree *Tree) Get(key interface{}) (value interface{}, found bool) {
+func (tree *Tree[K, V]) Get(key K) (value V, found bool) {
 	node := tree.lookup(key)
 	if node != nil {
 		return node.Value, true
 	}
 
-	return nil, false
+	return value, false
 }
 
 // Remove remove the node from the tree by key
 // Key should adhere to the comparator's type assertion, otherwise method panics.
-func (tree *Tree) Remove(key interface{}) {
-	var child *Node
+func (tree *Tree[K, V]) Remove(key K) {
+	if tree.persistent {
+		tree.removePersistent(key)
+		return
+	}
+
+	var child *Node[K, V]
 	node := tree.lookup(key)
 	if node == nil {
 		return
@@ -158,24 +213,31 @@ func (tree *Tree) Remove(key interface{}) {
 		if node.Parent == nil && child != nil {
 			child.color = black
 		}
+
+		// node is detached now, rotations above kept the sizes of its ancestors counting it
+		for parent := node.Parent; parent != nil; parent = parent.Parent {
+			parent.size--
+		}
+
+		tree.augmentPath(node.Parent)
 	}
 
 	tree.size--
 }
 
 // Empty returns true if tree does not contain any nodes
-func (tree *Tree) Empty() bool {
+func (tree *Tree[K, V]) Empty() bool {
 	return tree.size == 0
 }
 
 // Size returns number of nodes in the tree
-func (tree *Tree) Size() int {
+func (tree *Tree[K, V]) Size() int {
 	return tree.size
 }
 
 // Keys returns all keys in-order
-func (tree *Tree) Keys() []interface{} {
-	keys := make([]interface{}, tree.size)
+func (tree *Tree[K, V]) Keys() []K {
+	keys := make([]K, tree.size)
 
 	it := tree.Iterator()
 
@@ -187,8 +249,8 @@ func (tree *Tree) Keys() []interface{} {
 }
 
 // Values returns all values in-order based on the key.
-func (tree *Tree) Values() []interface{} {
-	values := make([]interface{}, tree.size)
+func (tree *Tree[K, V]) Values() []V {
+	values := make([]V, tree.size)
 
 	it := tree.Iterator()
 
@@ -207,7 +269,7 @@ func (tree *Tree) Values() []interface{} {
 // larger than the given node
 //
 // Key should adhere to the comparator's type assertion, otherwise method panics
-func (tree *Tree) Floor(key interface{}) (floor *Node, found bool) {
+func (tree *Tree[K, V]) Floor(key K) (floor *Node[K, V], found bool) {
 	found = false
 
 	node := tree.Root
@@ -241,7 +303,7 @@ func (tree *Tree) Floor(key interface{}) (floor *Node, found bool) {
 // are smaller than the given node
 //
 // Key should adhere to the comparator's type assertion, otherwise method panics.
-func (tree *Tree) Ceiling(key interface{}) (ceiling *Node, found bool) {
+func (tree *Tree[K, V]) Ceiling(key K) (ceiling *Node[K, V], found bool) {
 
 	found = false
 
@@ -267,14 +329,73 @@ func (tree *Tree) Ceiling(key interface{}) (ceiling *Node, found bool) {
 	return nil, false
 }
 
+// Rank returns the number of keys in the tree strictly smaller than key in O(lgn),
+// which is also the index key has (or would have) in the in-order sequence.
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) Rank(key K) int {
+	rank := 0
+
+	node := tree.Root
+
+	for node != nil {
+		compare := tree.Comparator(key, node.Key)
+		switch {
+		case compare == 0:
+			return rank + nodeSize(node.Left)
+		case compare < 0:
+			node = node.Left
+		case compare > 0:
+			rank += nodeSize(node.Left) + 1
+			node = node.Right
+		}
+	}
+
+	return rank
+}
+
+// Select returns the node holding the k-th smallest key (counting from 0) in O(lgn).
+// Second return parameter is false if k is out of range [0, Size())
+func (tree *Tree[K, V]) Select(k int) (node *Node[K, V], found bool) {
+	if k < 0 || k >= tree.size {
+		return nil, false
+	}
+
+	node = tree.Root
+
+	for node != nil {
+		left := nodeSize(node.Left)
+		switch {
+		case k == left:
+			return node, true
+		case k < left:
+			node = node.Left
+		default:
+			k -= left + 1
+			node = node.Right
+		}
+	}
+
+	return nil, false
+}
+
+// CountRange returns the number of keys in range [lo, hi) in O(lgn)
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (tree *Tree[K, V]) CountRange(lo, hi K) int {
+	if tree.Comparator(lo, hi) >= 0 {
+		return 0
+	}
+
+	return tree.Rank(hi) - tree.Rank(lo)
+}
+
 // Clear removes all nodes from the tree
-func (tree *Tree) Clear() {
+func (tree *Tree[K, V]) Clear() {
 	tree.Root = nil
 	tree.size = 0
 }
 
 // String returns a string representation of container
-func (tree *Tree) String() string {
+func (tree *Tree[K, V]) String() string {
 	str := "REDBLACKTREE\n"
 
 	if !tree.Empty() {
@@ -284,13 +405,13 @@ func (tree *Tree) String() string {
 	return str
 }
 
-func (node *Node) String() string {
+func (node *Node[K, V]) String() string {
 	return fmt.Sprintf("%v", node.Key)
 }
 
 /** inner function related */
 
-func output(node *Node, prefix string, isTail bool, str *string) {
+func output[K, V any](node *Node[K, V], prefix string, isTail bool, str *string) {
 	if node.Right != nil {
 		newPrefix := prefix
 		if isTail {
@@ -324,7 +445,7 @@ func output(node *Node, prefix string, isTail bool, str *string) {
 	}
 }
 
-func (tree *Tree) lookup(key interface{}) *Node {
+func (tree *Tree[K, V]) lookup(key K) *Node[K, V] {
 
 	node := tree.Root
 
@@ -343,7 +464,7 @@ func (tree *Tree) lookup(key interface{}) *Node {
 }
 
 // get maximum Node
-func (node *Node) maximumNode() *Node {
+func (node *Node[K, V]) maximumNode() *Node[K, V] {
 	if node == nil {
 		return nil
 	}
@@ -357,7 +478,7 @@ func (node *Node) maximumNode() *Node {
 
 // those cases use to balance tree
 
-func (tree *Tree) insertCase1(node *Node) {
+func (tree *Tree[K, V]) insertCase1(node *Node[K, V]) {
 	if node.Parent == nil {
 		node.color = black
 	} else {
@@ -365,7 +486,7 @@ func (tree *Tree) insertCase1(node *Node) {
 	}
 }
 
-func (tree *Tree) insertCase2(node *Node) {
+func (tree *Tree[K, V]) insertCase2(node *Node[K, V]) {
 	if nodeColor(node.Parent) == black {
 		return
 	}
@@ -373,7 +494,7 @@ func (tree *Tree) insertCase2(node *Node) {
 	tree.insertCase3(node)
 }
 
-func (tree *Tree) insertCase3(node *Node) {
+func (tree *Tree[K, V]) insertCase3(node *Node[K, V]) {
 	uncle := node.uncle()
 	if nodeColor(uncle) == red {
 		node.Parent.color = black
@@ -385,7 +506,7 @@ func (tree *Tree) insertCase3(node *Node) {
 	}
 }
 
-func (tree *Tree) insertCase4(node *Node) {
+func (tree *Tree[K, V]) insertCase4(node *Node[K, V]) {
 	grandparent := node.grandparent()
 	if node == node.Parent.Right && node.Parent == grandparent.Left {
 		tree.leftRotate(node.Parent)
@@ -398,7 +519,7 @@ func (tree *Tree) insertCase4(node *Node) {
 	tree.insertCase5(node)
 }
 
-func (tree *Tree) insertCase5(node *Node) {
+func (tree *Tree[K, V]) insertCase5(node *Node[K, V]) {
 	node.Parent.color = black
 	grandparent := node.grandparent()
 	grandparent.color = red
@@ -409,7 +530,7 @@ func (tree *Tree) insertCase5(node *Node) {
 	}
 }
 
-func (tree *Tree) deleteCase1(node *Node) {
+func (tree *Tree[K, V]) deleteCase1(node *Node[K, V]) {
 	if node.Parent == nil {
 		return
 	}
@@ -417,7 +538,7 @@ func (tree *Tree) deleteCase1(node *Node) {
 	tree.deleteCase2(node)
 }
 
-func (tree *Tree) deleteCase2(node *Node) {
+func (tree *Tree[K, V]) deleteCase2(node *Node[K, V]) {
 	sibling := node.sibling()
 	if nodeColor(sibling) == red {
 		node.Parent.color = red
@@ -433,7 +554,7 @@ func (tree *Tree) deleteCase2(node *Node) {
 	tree.deleteCase3(node)
 }
 
-func (tree *Tree) deleteCase3(node *Node) {
+func (tree *Tree[K, V]) deleteCase3(node *Node[K, V]) {
 
 	sibling := node.sibling()
 
@@ -448,7 +569,7 @@ func (tree *Tree) deleteCase3(node *Node) {
 	}
 }
 
-func (tree *Tree) deleteCase4(node *Node) {
+func (tree *Tree[K, V]) deleteCase4(node *Node[K, V]) {
 	sibling := node.sibling()
 
 	if nodeColor(node.Parent) == red &&
@@ -462,7 +583,7 @@ func (tree *Tree) deleteCase4(node *Node) {
 	}
 }
 
-func (tree *Tree) deleteCase5(node *Node) {
+func (tree *Tree[K, V]) deleteCase5(node *Node[K, V]) {
 	sibling := node.sibling()
 
 	if node == node.Parent.Left &&
@@ -484,7 +605,7 @@ func (tree *Tree) deleteCase5(node *Node) {
 	tree.deleteCase6(node)
 }
 
-func (tree *Tree) deleteCase6(node *Node) {
+func (tree *Tree[K, V]) deleteCase6(node *Node[K, V]) {
 	sibling := node.sibling()
 	sibling.color = nodeColor(node.Parent)
 	node.Parent.color = black
@@ -500,8 +621,8 @@ func (tree *Tree) deleteCase6(node *Node) {
 /** direction related */
 
 // Left returns the left-most (min) node or nil it tree is empty
-func (tree *Tree) Left() *Node {
-	var parent *Node
+func (tree *Tree[K, V]) Left() *Node[K, V] {
+	var parent *Node[K, V]
 
 	current := tree.Root
 
@@ -514,8 +635,8 @@ func (tree *Tree) Left() *Node {
 }
 
 // Right returns the right-most (max) node or nil if tree was empty
-func (tree *Tree) Right() *Node {
-	var parent *Node
+func (tree *Tree[K, V]) Right() *Node[K, V] {
+	var parent *Node[K, V]
 
 	current := tree.Root
 
@@ -527,7 +648,7 @@ func (tree *Tree) Right() *Node {
 	return parent
 }
 
-func (tree *Tree) leftRotate(node *Node) {
+func (tree *Tree[K, V]) leftRotate(node *Node[K, V]) {
 	right := node.Right
 	tree.replaceNode(node, right)
 	node.Right = right.Left
@@ -536,9 +657,17 @@ func (tree *Tree) leftRotate(node *Node) {
 	}
 	right.Left = node
 	node.Parent = right
+
+	right.size = node.size
+	node.size = nodeSize(node.Left) + nodeSize(node.Right) + 1
+
+	if tree.augment != nil {
+		tree.augment(node)
+		tree.augment(right)
+	}
 }
 
-func (tree *Tree) rightRotate(node *Node) {
+func (tree *Tree[K, V]) rightRotate(node *Node[K, V]) {
 	left := node.Left
 	tree.replaceNode(node, left)
 	node.Left = left.Right
@@ -548,9 +677,17 @@ func (tree *Tree) rightRotate(node *Node) {
 
 	left.Right = node
 	node.Parent = left
+
+	left.size = node.size
+	node.size = nodeSize(node.Left) + nodeSize(node.Right) + 1
+
+	if tree.augment != nil {
+		tree.augment(node)
+		tree.augment(left)
+	}
 }
 
-func (tree *Tree) replaceNode(old *Node, new *Node) {
+func (tree *Tree[K, V]) replaceNode(old *Node[K, V], new *Node[K, V]) {
 	if old.Parent == nil {
 		tree.Root = new
 	} else {
@@ -567,7 +704,7 @@ func (tree *Tree) replaceNode(old *Node, new *Node) {
 }
 
 /** relationship related */
-func (node *Node) grandparent() *Node {
+func (node *Node[K, V]) grandparent() *Node[K, V] {
 	if node != nil && node.Parent != nil {
 		return node.Parent.Parent
 	}
@@ -575,7 +712,7 @@ func (node *Node) grandparent() *Node {
 	return nil
 }
 
-func (node *Node) uncle() *Node {
+func (node *Node[K, V]) uncle() *Node[K, V] {
 	if node == nil || node.Parent == nil || node.Parent.Parent == nil {
 		return nil
 	}
@@ -583,7 +720,7 @@ func (node *Node) uncle() *Node {
 	return node.Parent.sibling()
 }
 
-func (node *Node) sibling() *Node {
+func (node *Node[K, V]) sibling() *Node[K, V] {
 	if node == nil || node.Parent == nil {
 		return nil
 	}
@@ -595,9 +732,30 @@ func (node *Node) sibling() *Node {
 	return node.Parent.Left
 }
 
+// augmentPath recomputes the metadata of node and its ancestors bottom-up
+func (tree *Tree[K, V]) augmentPath(node *Node[K, V]) {
+	if tree.augment == nil {
+		return
+	}
+
+	for ; node != nil; node = node.Parent {
+		tree.augment(node)
+	}
+}
+
+/** size related */
+
+func nodeSize[K, V any](node *Node[K, V]) int {
+	if node == nil {
+		return 0
+	}
+
+	return node.size
+}
+
 /** color related */
 
-func nodeColor(node *Node) color {
+func nodeColor[K, V any](node *Node[K, V]) color {
 	if node == nil {
 		return black
 	}
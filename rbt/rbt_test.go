@@ -6,8 +6,16 @@
 package rbt
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"net"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/Jayj1997/go-common/comparator"
 )
 
 func TestRedBlackTree(t *testing.T) {
@@ -563,6 +571,512 @@ func TestRedBlackTreeIteratorLast(t *testing.T) {
 	}
 }
 
+func TestRedBlackTreeIteratorFrom(t *testing.T) {
+	tree := New[int, string]()
+	for _, key := range []int{10, 20, 30, 40} {
+		tree.Insert(key, fmt.Sprint(key))
+	}
+
+	tests := [][]interface{}{
+		// key, next
+		{5, 10},
+		{10, 10},
+		{15, 20},
+		{20, 20},
+		{40, 40},
+		{45, nil},
+	}
+
+	for _, test := range tests {
+		it := tree.IteratorFrom(test[0].(int))
+		var next interface{}
+		if it.Next() {
+			next = it.Key()
+		}
+		if next != test[1] {
+			t.Errorf("Got %v expected %v for key %v", next, test[1], test[0])
+		}
+	}
+
+	empty := New[int, string]()
+	if it := empty.IteratorFrom(1); it.Next() {
+		t.Errorf("Shouldn't iterate on empty tree")
+	}
+}
+
+func TestRedBlackTreeRange(t *testing.T) {
+	tree := New[int, int]()
+	for i := 1; i <= 10; i++ {
+		tree.Insert(i*10, i)
+	}
+
+	collect := func(lo, hi int, loInclusive, hiInclusive, reverse bool, limit int) string {
+		keys := []int{}
+		fn := func(key, value int) bool {
+			keys = append(keys, key)
+			return len(keys) < limit
+		}
+		if reverse {
+			tree.ReverseRange(lo, hi, loInclusive, hiInclusive, fn)
+		} else {
+			tree.Range(lo, hi, loInclusive, hiInclusive, fn)
+		}
+		return fmt.Sprint(keys)
+	}
+
+	tests := []struct {
+		lo, hi                   int
+		loInclusive, hiInclusive bool
+		reverse                  bool
+		limit                    int
+		expected                 string
+	}{
+		{20, 50, true, false, false, 100, "[20 30 40]"},
+		{20, 50, true, true, false, 100, "[20 30 40 50]"},
+		{20, 50, false, true, false, 100, "[30 40 50]"},
+		{15, 55, false, false, false, 100, "[20 30 40 50]"},
+		{0, 1000, true, true, false, 3, "[10 20 30]"},
+		{101, 200, true, true, false, 100, "[]"},
+		{50, 20, true, true, false, 100, "[]"},
+		{20, 50, true, false, true, 100, "[40 30 20]"},
+		{20, 50, false, true, true, 100, "[50 40 30]"},
+		{15, 55, true, true, true, 100, "[50 40 30 20]"},
+		{0, 1000, true, true, true, 2, "[100 90]"},
+		{0, 5, true, true, true, 100, "[]"},
+	}
+
+	for _, test := range tests {
+		actualValue := collect(test.lo, test.hi, test.loInclusive, test.hiInclusive, test.reverse, test.limit)
+		if actualValue != test.expected {
+			t.Errorf("Got %v expected %v for %+v", actualValue, test.expected, test)
+		}
+	}
+}
+
+func TestRedBlackTreeRankAndSelect(t *testing.T) {
+	tree := New[int, string]()
+
+	if actualValue := tree.Rank(1); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	if node, found := tree.Select(0); node != nil || found {
+		t.Errorf("Got %v expected %v", node, "<nil>")
+	}
+
+	for _, key := range []int{50, 10, 40, 20, 30} {
+		tree.Insert(key, fmt.Sprint(key))
+	}
+	tree.Insert(30, "overwrite")
+
+	tests := [][]interface{}{
+		// key, rank
+		{5, 0},
+		{10, 0},
+		{15, 1},
+		{30, 2},
+		{50, 4},
+		{55, 5},
+	}
+	for _, test := range tests {
+		if actualValue := tree.Rank(test[0].(int)); actualValue != test[1] {
+			t.Errorf("Got %v expected %v for rank of %v", actualValue, test[1], test[0])
+		}
+	}
+
+	for k, expectedKey := range []int{10, 20, 30, 40, 50} {
+		if node, found := tree.Select(k); node.Key != expectedKey || !found {
+			t.Errorf("Got %v expected %v for select of %v", node, expectedKey, k)
+		}
+	}
+	if node, found := tree.Select(5); node != nil || found {
+		t.Errorf("Got %v expected %v", node, "<nil>")
+	}
+	if node, found := tree.Select(-1); node != nil || found {
+		t.Errorf("Got %v expected %v", node, "<nil>")
+	}
+
+	if actualValue := tree.CountRange(15, 45); actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+	if actualValue := tree.CountRange(10, 50); actualValue != 4 {
+		t.Errorf("Got %v expected %v", actualValue, 4)
+	}
+	if actualValue := tree.CountRange(50, 10); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+}
+
+func TestRedBlackTreeRankAndSelectRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	tree := New[int, int]()
+	present := map[int]bool{}
+
+	var assertSize func(node *Node[int, int]) int
+	assertSize = func(node *Node[int, int]) int {
+		if node == nil {
+			return 0
+		}
+		size := assertSize(node.Left) + assertSize(node.Right) + 1
+		if node.size != size {
+			t.Fatalf("Got %v expected %v for size of %v", node.size, size, node.Key)
+		}
+		return size
+	}
+
+	for i := 0; i < 2000; i++ {
+		key := random.Intn(500)
+		if random.Intn(3) == 0 {
+			tree.Remove(key)
+			delete(present, key)
+		} else {
+			tree.Insert(key, key)
+			present[key] = true
+		}
+
+		if actualValue := assertSize(tree.Root); actualValue != tree.Size() {
+			t.Fatalf("Got %v expected %v", actualValue, tree.Size())
+		}
+	}
+
+	rank := 0
+	for key := 0; key < 500; key++ {
+		if actualValue := tree.Rank(key); actualValue != rank {
+			t.Errorf("Got %v expected %v for rank of %v", actualValue, rank, key)
+		}
+		if present[key] {
+			if node, found := tree.Select(rank); !found || node.Key != key {
+				t.Errorf("Got %v expected %v for select of %v", node, key, rank)
+			}
+			rank++
+		}
+	}
+}
+
+// assertRedBlack checks red-black properties and subtree sizes without relying on Parent,
+// returns the black height of node
+func assertRedBlack(t *testing.T, node *Node[int, int]) int {
+	if node == nil {
+		return 1
+	}
+	if node.color == red && (nodeColor(node.Left) == red || nodeColor(node.Right) == red) {
+		t.Fatalf("Red node %v has red child", node.Key)
+	}
+	if node.Left != nil && node.Left.Key >= node.Key || node.Right != nil && node.Right.Key <= node.Key {
+		t.Fatalf("Node %v is out of order", node.Key)
+	}
+	left, right := assertRedBlack(t, node.Left), assertRedBlack(t, node.Right)
+	if left != right {
+		t.Fatalf("Got black height %v and %v under %v", left, right, node.Key)
+	}
+	if size := nodeSize(node.Left) + nodeSize(node.Right) + 1; node.size != size {
+		t.Fatalf("Got %v expected %v for size of %v", node.size, size, node.Key)
+	}
+	if node.color == black {
+		return left + 1
+	}
+	return left
+}
+
+func TestRedBlackTreeValidate(t *testing.T) {
+	tree := New[int, int]()
+	if err := tree.Validate(); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, i)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+
+	corruptions := []func(tree *Tree[int, int]){
+		func(tree *Tree[int, int]) { tree.Root.color = red },
+		func(tree *Tree[int, int]) { tree.Root.Left.color = !tree.Root.Left.color },
+		func(tree *Tree[int, int]) { tree.Right().color, tree.Right().Parent.color = red, red },
+		func(tree *Tree[int, int]) { tree.Left().Key = 1000 },
+		func(tree *Tree[int, int]) { tree.Left().Parent = nil },
+		func(tree *Tree[int, int]) { tree.Root.size++ },
+		func(tree *Tree[int, int]) { tree.size-- },
+	}
+	for i, corrupt := range corruptions {
+		tree := New[int, int]()
+		for i := 0; i < 100; i++ {
+			tree.Insert(i, i)
+		}
+		corrupt(tree)
+		if err := tree.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Got %v expected %v for corruption %v", err, ErrInvalid, i)
+		}
+	}
+}
+
+func FuzzRedBlackTree(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	f.Add([]byte{9, 8, 7, 6, 5, 4, 3, 2, 1, 0, 128, 130, 135, 129})
+	f.Add([]byte{3, 3, 131, 3, 131, 200, 72, 200})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		tree, persistent := New[int, int](), New[int, int]().Persistent()
+		expected := map[int]int{}
+
+		// the high bit of a byte removes the key held by the low bits, otherwise it is inserted
+		for i, op := range ops {
+			key := int(op & 0x7f)
+			if op&0x80 != 0 {
+				tree.Remove(key)
+				persistent.Remove(key)
+				delete(expected, key)
+			} else {
+				tree.Insert(key, i)
+				persistent.Insert(key, i)
+				expected[key] = i
+			}
+
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("Got error %v after op %v", err, i)
+			}
+			if err := persistent.Validate(); err != nil {
+				t.Fatalf("Got error %v after op %v of persistent tree", err, i)
+			}
+		}
+
+		if actualValue, expectedValue := tree.Size(), len(expected); actualValue != expectedValue || persistent.Size() != expectedValue {
+			t.Fatalf("Got %v,%v expected %v", actualValue, persistent.Size(), expectedValue)
+		}
+		for key, value := range expected {
+			if actualValue, found := tree.Get(key); actualValue != value || !found {
+				t.Fatalf("Got %v,%v expected %v,%v", actualValue, found, value, true)
+			}
+		}
+	})
+}
+
+func TestRedBlackTreePersistent(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	tree := New[int, int]().Persistent()
+	present := map[int]int{}
+
+	for i := 0; i < 3000; i++ {
+		key := random.Intn(300)
+		if random.Intn(3) == 0 {
+			tree.Remove(key)
+			delete(present, key)
+		} else {
+			tree.Insert(key, i)
+			present[key] = i
+		}
+
+		if nodeColor(tree.Root) != black {
+			t.Fatalf("Root is not black")
+		}
+		assertRedBlack(t, tree.Root)
+		if actualValue, expectedValue := tree.Size(), len(present); actualValue != expectedValue {
+			t.Fatalf("Got %v expected %v", actualValue, expectedValue)
+		}
+	}
+
+	for key, expectedValue := range present {
+		if actualValue, found := tree.Get(key); actualValue != expectedValue || !found {
+			t.Errorf("Got %v expected %v for key %v", actualValue, expectedValue, key)
+		}
+	}
+
+	it := tree.Iterator()
+	count, previous := 0, -1
+	for it.Next() {
+		if it.Key() <= previous {
+			t.Errorf("Got %v after %v", it.Key(), previous)
+		}
+		previous = it.Key()
+		count++
+	}
+	for it.Previous() {
+		count--
+	}
+	if count != 0 {
+		t.Errorf("Got %v expected %v", count, 0)
+	}
+}
+
+func TestRedBlackTreeSnapshot(t *testing.T) {
+	tree := New[int, string]()
+	for i := 1; i <= 5; i++ {
+		tree.Insert(i, fmt.Sprint(i))
+	}
+
+	// snapshot switches a plain tree into persistent mode
+	snapshot := tree.Snapshot()
+	if !tree.IsPersistent() {
+		t.Errorf("Got %v expected %v", tree.IsPersistent(), true)
+	}
+
+	it := snapshot.Iterator()
+	keys := []int{}
+	for i := 6; it.Next(); i++ {
+		keys = append(keys, it.Key())
+		tree.Remove(it.Key())
+		tree.Insert(i*10, "new")
+		tree.Insert(it.Key()+1, "changed")
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(keys), "[1 2 3 4 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(snapshot.Values()), "[1 2 3 4 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[6 60 70 80 90 100]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := snapshot.Get(3); value != "3" || !found {
+		t.Errorf("Got %v expected %v", value, "3")
+	}
+	if node, found := snapshot.Select(4); node.Key != 5 || !found {
+		t.Errorf("Got %v expected %v", node, 5)
+	}
+	if actualValue, expectedValue := snapshot.Size(), 5; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	it = snapshot.IteratorFrom(3)
+	if !it.Next() || it.Key() != 3 || !it.Previous() || it.Key() != 2 {
+		t.Errorf("Got %v expected %v", it.Key(), 2)
+	}
+}
+
+func TestRedBlackTreeSnapshotVersions(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := New[int, int]().Persistent()
+	snapshots := []*Snapshot[int, int]{}
+	expected := []string{}
+
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 20; j++ {
+			key := random.Intn(100)
+			if random.Intn(2) == 0 {
+				tree.Remove(key)
+			} else {
+				tree.Insert(key, i)
+			}
+		}
+		snapshots = append(snapshots, tree.Snapshot())
+		expected = append(expected, fmt.Sprint(tree.Keys(), tree.Values()))
+	}
+
+	for i, snapshot := range snapshots {
+		if actualValue := fmt.Sprint(snapshot.Keys(), snapshot.Values()); actualValue != expected[i] {
+			t.Errorf("Got %v expected %v for version %v", actualValue, expected[i], i)
+		}
+	}
+}
+
+func TestRedBlackTreeSync(t *testing.T) {
+	tree := NewSync[int, int]()
+
+	if actual, loaded := tree.GetOrInsert(1, 10); actual != 10 || loaded {
+		t.Errorf("Got %v,%v expected %v,%v", actual, loaded, 10, false)
+	}
+	if actual, loaded := tree.GetOrInsert(1, 20); actual != 10 || !loaded {
+		t.Errorf("Got %v,%v expected %v,%v", actual, loaded, 10, true)
+	}
+
+	if swapped := CompareAndSwap(tree, 1, 20, 30); swapped {
+		t.Errorf("Got %v expected %v", swapped, false)
+	}
+	if swapped := CompareAndSwap(tree, 1, 10, 30); !swapped {
+		t.Errorf("Got %v expected %v", swapped, true)
+	}
+	if swapped := CompareAndSwap(tree, 2, 0, 30); swapped {
+		t.Errorf("Got %v expected %v", swapped, false)
+	}
+
+	lists := NewSync[int, []int]()
+	lists.Insert(1, []int{1, 2})
+	equal := func(a, b []int) bool {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	if swapped := lists.CompareAndSwapFunc(1, []int{1, 3}, []int{3}, equal); swapped {
+		t.Errorf("Got %v expected %v", swapped, false)
+	}
+	if swapped := lists.CompareAndSwapFunc(1, []int{1, 2}, []int{3}, equal); !swapped {
+		t.Errorf("Got %v expected %v", swapped, true)
+	}
+	if value, _ := lists.Get(1); fmt.Sprint(value) != "[3]" {
+		t.Errorf("Got %v expected %v", value, "[3]")
+	}
+
+	increment := func(old int, found bool) (int, bool) {
+		return old + 1, true
+	}
+	tree.Update(1, increment)
+	tree.Update(2, increment)
+	if value, found := tree.Get(1); value != 31 || !found {
+		t.Errorf("Got %v expected %v", value, 31)
+	}
+	if value, found := tree.Get(2); value != 1 || !found {
+		t.Errorf("Got %v expected %v", value, 1)
+	}
+
+	tree.Update(2, func(old int, found bool) (int, bool) {
+		return 0, false
+	})
+	if value, found := tree.Get(2); found {
+		t.Errorf("Got %v expected %v", value, "<nil>")
+	}
+	if actualValue := tree.Size(); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
+
+func TestRedBlackTreeSyncConcurrent(t *testing.T) {
+	tree := NewSync[int, int]()
+	for i := 0; i < 100; i++ {
+		tree.Insert(i, 0)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				tree.Update(i%100, func(old int, found bool) (int, bool) {
+					return old + 1, true
+				})
+				tree.Insert(100+i%50, i)
+				tree.Remove(100 + i%50)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				// iterate without holding the lock, writers keep going meanwhile
+				it := tree.Iterator()
+				previous := -1
+				for it.Next() {
+					if it.Key() <= previous {
+						t.Errorf("Got %v after %v", it.Key(), previous)
+					}
+					previous = it.Key()
+					tree.Get(it.Key())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sum := 0
+	for _, value := range tree.Values() {
+		sum += value
+	}
+	if actualValue, expectedValue := sum, 4000; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := tree.Size(), 100; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
 func TestRedBlackTreeSerialization(t *testing.T) {
 	tree := NewWithStringComparator()
 	tree.Insert("c", "3")
@@ -594,7 +1108,224 @@ func TestRedBlackTreeSerialization(t *testing.T) {
 	assert()
 }
 
-func benchmarkGet(b *testing.B, tree *Tree, size int) {
+func TestRedBlackTreeJSON(t *testing.T) {
+	tree := NewWithIntComparator()
+	tree.Insert(10, "a")
+	tree.Insert(2, 1.5)
+	tree.Insert(-1, nil)
+
+	data, err := tree.ToJSON()
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := string(data), `[{"key":-1,"value":null},{"key":2,"value":1.5},{"key":10,"value":"a"}]`; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// int keys survive the round-trip, so IntComparator doesn't panic
+	loaded := NewWithIntComparator()
+	if err := loaded.FromJSON(data); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(loaded.Keys()), "[-1 2 10]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := loaded.Get(10); value != "a" || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, "a", true)
+	}
+
+	// typed trees and custom key decoders
+	typed := New[float64, string]()
+	if err := typed.UnmarshalJSON([]byte(`[{"key":2.5,"value":"b"},{"key":-1,"value":"a"}]`)); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(typed.Keys()), "[-1 2.5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	int64s := NewWith(comparator.Int64Comparator)
+	int64s.KeyDecoder = KeyDecoderOf[int64]()
+	if err := int64s.FromJSON([]byte(`[{"key":3,"value":"c"},{"key":1,"value":"a"}]`)); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(int64s.Keys()), "[1 3]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// malformed input leaves the tree untouched
+	for _, input := range []string{`{"1":"a"}`, `[{"key":"x","value":1}]`, `[`} {
+		if err := loaded.FromJSON([]byte(input)); err == nil {
+			t.Errorf("Got %v expected error for %v", err, input)
+		}
+	}
+	if actualValue, expectedValue := loaded.Size(), 3; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestRedBlackTreeJSONEmbedded(t *testing.T) {
+	type index struct {
+		Name  string
+		Items *Tree[string, int]
+	}
+
+	expected := index{Name: "items", Items: New[string, int]()}
+	expected.Items.Insert("b", 2)
+	expected.Items.Insert("a", 1)
+
+	data, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := string(data), `{"Name":"items","Items":[{"key":"a","value":1},{"key":"b","value":2}]}`; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	actual := index{Items: New[string, int]()}
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(actual.Items.Keys(), actual.Items.Values()), "[a b] [1 2]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// a tree allocated by encoding/json has no comparator
+	if err := json.Unmarshal(data, &index{}); err == nil {
+		t.Errorf("Got %v expected error", err)
+	}
+}
+
+func TestRedBlackTreeEnumerable(t *testing.T) {
+	tree := New[int, string]()
+	for i := 10; i > 0; i-- {
+		tree.Insert(i, fmt.Sprint(i))
+	}
+
+	keys := ""
+	tree.Each(func(key int, value string) bool {
+		keys += value + " "
+		return key < 4
+	})
+	if actualValue, expectedValue := keys, "1 2 3 4 "; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	mapped := tree.Map(func(key int, value string) (int, string) {
+		return -key, value + "!"
+	})
+	if actualValue, expectedValue := fmt.Sprint(mapped.Keys(), mapped.Values()[0]), "[-10 -9 -8 -7 -6 -5 -4 -3 -2 -1]10!"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	even := tree.Filter(func(key int, value string) bool {
+		return key%2 == 0
+	})
+	if actualValue, expectedValue := fmt.Sprint(even.Keys(), even.Values()), "[2 4 6 8 10] [2 4 6 8 10]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if err := even.Validate(); err != nil {
+		t.Errorf("Got error %v", err)
+	}
+
+	visited := 0
+	if !tree.Any(func(key int, value string) bool { visited++; return key > 2 }) || visited != 3 {
+		t.Errorf("Got %v visited expected %v", visited, 3)
+	}
+	if tree.Any(func(key int, value string) bool { return key > 10 }) {
+		t.Errorf("Got %v expected %v", true, false)
+	}
+	if !even.All(func(key int, value string) bool { return key%2 == 0 }) {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+	visited = 0
+	if tree.All(func(key int, value string) bool { visited++; return key < 5 }) || visited != 5 {
+		t.Errorf("Got %v visited expected %v", visited, 5)
+	}
+	if key, value, found := tree.Find(func(key int, value string) bool { return key*key > 20 }); key != 5 || value != "5" || !found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, 5, "5", true)
+	}
+	if key, value, found := tree.Find(func(key int, value string) bool { return false }); key != 0 || value != "" || found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, 0, "", false)
+	}
+
+	joined := Reduce(tree, "", func(accumulator string, key int, value string) string {
+		return accumulator + value
+	})
+	if actualValue, expectedValue := joined, "12345678910"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := Reduce(New[int, string](), 0, func(sum int, key int, value string) int { return sum + key }), 0; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestRedBlackTreeGeneric(t *testing.T) {
+	tree := New[int, string]()
+	tree.Insert(5, "e")
+	tree.Insert(6, "f")
+	tree.Insert(7, "g")
+	tree.Insert(3, "c")
+	tree.Insert(4, "d")
+	tree.Insert(1, "x")
+	tree.Insert(2, "b")
+	tree.Insert(1, "a") // overwrite
+
+	if actualValue := tree.Size(); actualValue != 7 {
+		t.Errorf("Got %v expected %v", actualValue, 7)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 3 4 5 6 7]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Values()), "[a b c d e f g]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := tree.Get(8); value != "" || found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, "", false)
+	}
+	if node, found := tree.Floor(0); node != nil || found {
+		t.Errorf("Got %v expected %v", node, "<nil>")
+	}
+	if node, found := tree.Ceiling(0); node.Key != 1 || !found {
+		t.Errorf("Got %v expected %v", node, 1)
+	}
+
+	tree.Remove(5)
+	tree.Remove(1)
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[2 3 4 6 7]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	it := tree.Iterator()
+	for it.Last(); it.Previous(); {
+	}
+	if it.Next(); it.Key() != 2 || it.Value() != "b" {
+		t.Errorf("Got %v,%v expected %v,%v", it.Key(), it.Value(), 2, "b")
+	}
+}
+
+func TestRedBlackTreeGenericWithFunc(t *testing.T) {
+	type score struct {
+		name  string
+		point int
+	}
+
+	// order by point descending
+	tree := NewWithFunc[score, struct{}](func(a, b score) int {
+		return b.point - a.point
+	})
+	tree.Insert(score{"a", 1}, struct{}{})
+	tree.Insert(score{"b", 3}, struct{}{})
+	tree.Insert(score{"c", 2}, struct{}{})
+
+	names := ""
+	for _, key := range tree.Keys() {
+		names += key.name
+	}
+	if actualValue, expectedValue := names, "bca"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func benchmarkGet(b *testing.B, tree *Tree[interface{}, interface{}], size int) {
 	for i := 0; i < b.N; i++ {
 		for n := 0; n < size; n++ {
 			tree.Get(n)
@@ -602,7 +1333,7 @@ func benchmarkGet(b *testing.B, tree *Tree, size int) {
 	}
 }
 
-func benchmarkInsert(b *testing.B, tree *Tree, size int) {
+func benchmarkInsert(b *testing.B, tree *Tree[interface{}, interface{}], size int) {
 	for i := 0; i < b.N; i++ {
 		for n := 0; n < size; n++ {
 			tree.Insert(n, struct{}{})
@@ -610,7 +1341,7 @@ func benchmarkInsert(b *testing.B, tree *Tree, size int) {
 	}
 }
 
-func benchmarkRemove(b *testing.B, tree *Tree, size int) {
+func benchmarkRemove(b *testing.B, tree *Tree[interface{}, interface{}], size int) {
 	for i := 0; i < b.N; i++ {
 		for n := 0; n < size; n++ {
 			tree.Remove(n)
@@ -749,3 +1480,355 @@ func BenchmarkRedBlackTreeRemove100000(b *testing.B) {
 	b.StartTimer()
 	benchmarkRemove(b, tree, size)
 }
+
+func benchmarkGenericGet(b *testing.B, tree *Tree[int, struct{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Get(n)
+		}
+	}
+}
+
+func benchmarkGenericInsert(b *testing.B, tree *Tree[int, struct{}], size int) {
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < size; n++ {
+			tree.Insert(n, struct{}{})
+		}
+	}
+}
+
+func BenchmarkRedBlackTreeGenericGet10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := New[int, struct{}]()
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericGet(b, tree, size)
+}
+
+func BenchmarkRedBlackTreeGenericGet100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := New[int, struct{}]()
+	for n := 0; n < size; n++ {
+		tree.Insert(n, struct{}{})
+	}
+	b.StartTimer()
+	benchmarkGenericGet(b, tree, size)
+}
+
+func BenchmarkRedBlackTreeGenericInsert10000(b *testing.B) {
+	b.StopTimer()
+	size := 10000
+	tree := New[int, struct{}]()
+	b.StartTimer()
+	benchmarkGenericInsert(b, tree, size)
+}
+
+func BenchmarkRedBlackTreeGenericInsert100000(b *testing.B) {
+	b.StopTimer()
+	size := 100000
+	tree := New[int, struct{}]()
+	b.StartTimer()
+	benchmarkGenericInsert(b, tree, size)
+}
+
+func TestRedBlackTreeTryMethods(t *testing.T) {
+
+	tree := NewWithIntComparator()
+
+	// the first key used to be accepted without comparison
+	if err := tree.TryInsert("a", "a"); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if actualValue := tree.Size(); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := tree.TryInsert(i, fmt.Sprint(i)); err != nil {
+			t.Errorf("Got %v expected %v", err, nil)
+		}
+	}
+
+	if err := tree.TryInsert(6.0, "6"); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if value, found, err := tree.TryGet(3); value != "3" || !found || err != nil {
+		t.Errorf("Got %v %v %v expected %v %v %v", value, found, err, "3", true, nil)
+	}
+
+	if value, found, err := tree.TryGet("3"); value != nil || found || !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v %v %v expected %v %v %v", value, found, err, nil, false, comparator.ErrKeyType)
+	}
+
+	if err := tree.TryRemove(int64(3)); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := tree.TryRemove(3); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 4 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	syncTree := NewSyncWith(comparator.StringComparator)
+
+	if err := syncTree.TryInsert(1, 1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := syncTree.TryInsert("a", 1); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+
+	if _, _, err := syncTree.TryGet(1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := syncTree.CheckKey(1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := syncTree.TryRemove("a"); err != nil || !syncTree.Empty() {
+		t.Errorf("Got %v %v expected %v %v", err, syncTree.Empty(), nil, true)
+	}
+
+	// comparators checking keys themselves report them the same way
+	ips := NewWith(comparator.IPComparator)
+
+	for _, key := range []interface{}{"not-an-ip", net.IP{1, 2, 3}} {
+		if err := ips.TryInsert(key, 1); !errors.Is(err, comparator.ErrKeyType) {
+			t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+		}
+	}
+
+	if err := ips.TryInsert(net.ParseIP("10.0.0.1"), 1); err != nil || ips.Size() != 1 {
+		t.Errorf("Got %v %v expected %v %v", err, ips.Size(), nil, 1)
+	}
+
+	tuples := NewWith(comparator.TupleComparator(comparator.IntComparator))
+
+	if err := tuples.TryInsert([]interface{}{1, 2}, 1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := tuples.TryInsert([]interface{}{"1"}, 1); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+
+	if err := tuples.TryInsert([]interface{}{1}, 1); err != nil || tuples.Size() != 1 {
+		t.Errorf("Got %v %v expected %v %v", err, tuples.Size(), nil, 1)
+	}
+}
+
+func TestRedBlackTreeAutoComparator(t *testing.T) {
+
+	// numbers of any kind are ordered numerically, large integers are kept exact
+	data := []byte(`[{"key":"b","value":1},{"key":9007199254740993,"value":2},{"key":2.5,"value":3},{"key":true,"value":4},{"key":-1,"value":5},{"key":null,"value":6}]`)
+
+	var tree Tree[interface{}, interface{}]
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[<nil> true -1 2.5 9007199254740993 b]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if value, found := tree.Get(int64(9007199254740993)); value != 2.0 || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 2.0, true)
+	}
+	if value, found := tree.Get(-1.0); value != 5.0 || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, 5.0, true)
+	}
+
+	loaded := NewWithAutoComparator()
+	if err := loaded.FromJSON(data); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	loaded.Insert(uint8(3), 7)
+	if actualValue, expectedValue := fmt.Sprint(loaded.Keys()), "[<nil> true -1 2.5 3 9007199254740993 b]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// keys Auto doesn't order are reported and leave the tree untouched
+	var untouched Tree[interface{}, interface{}]
+	if err := untouched.FromJSON([]byte(`[{"key":1,"value":1},{"key":[1],"value":2}]`)); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+	if untouched.Comparator != nil || untouched.KeyDecoder != nil || !untouched.Empty() {
+		t.Errorf("Got %v expected an untouched tree", untouched.Keys())
+	}
+	if err := loaded.TryInsert(map[string]interface{}{}, 8); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+}
+
+func TestIntervalTree(t *testing.T) {
+
+	intervals := NewInterval[int, string]()
+
+	intervals.Insert(15, 20, "a")
+	intervals.Insert(10, 30, "b")
+	intervals.Insert(17, 19, "c")
+	intervals.Insert(5, 20, "d")
+	intervals.Insert(30, 12, "e") // stored as [12, 30]
+	intervals.Insert(30, 40, "f")
+	intervals.Insert(17, 19, "g") // overwrite
+
+	if err := intervals.Validate(); err != nil {
+		t.Fatalf("Got %v", err)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(intervals.Intervals(), intervals.Values()), "[{5 20} {10 30} {12 30} {15 20} {17 19} {30 40}] [d b e a g f]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	tests := []struct {
+		low, high int
+		expected  string
+	}{
+		{6, 7, "[{5 20}] [d]"},
+		{21, 23, "[{10 30} {12 30}] [b e]"},
+		{40, 50, "[{30 40}] [f]"},
+		{41, 50, "[] []"},
+		{0, 4, "[] []"},
+		{19, 10, "[{5 20} {10 30} {12 30} {15 20} {17 19}] [d b e a g]"},
+	}
+
+	for _, test := range tests {
+		found, values := intervals.Overlapping(test.low, test.high)
+		if actualValue := fmt.Sprint(found, values); actualValue != test.expected {
+			t.Errorf("Got %v expected %v for [%v, %v]", actualValue, test.expected, test.low, test.high)
+		}
+	}
+
+	if found, values := intervals.Stabbing(30); fmt.Sprint(found, values) != "[{10 30} {12 30} {30 40}] [b e f]" {
+		t.Errorf("Got %v %v expected %v", found, values, "[{10 30} {12 30} {30 40}] [b e f]")
+	}
+
+	if value, found := intervals.Get(30, 12); value != "e" || !found {
+		t.Errorf("Got %v,%v expected %v,%v", value, found, "e", true)
+	}
+
+	if !intervals.Delete(12, 30) || intervals.Delete(12, 30) || intervals.Delete(1, 2) {
+		t.Errorf("Got unexpected result of Delete")
+	}
+
+	if found, _ := intervals.Stabbing(25); fmt.Sprint(found) != "[{10 30}]" {
+		t.Errorf("Got %v expected %v", found, "[{10 30}]")
+	}
+
+	intervals.Clear()
+
+	if found, _ := intervals.Stabbing(25); !intervals.Empty() || intervals.Size() != 0 || found != nil {
+		t.Errorf("Got %v expected an empty tree", found)
+	}
+}
+
+func TestIntervalTreeRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	intervals := NewInterval[int, int]()
+	expected := map[Interval[int]]int{}
+
+	for i := 0; i < 3000; i++ {
+
+		low := r.Intn(500)
+		interval := Interval[int]{Low: low, High: low + r.Intn(50)}
+
+		if r.Intn(3) == 0 {
+			deleted := intervals.Delete(interval.Low, interval.High)
+			if _, ok := expected[interval]; ok != deleted {
+				t.Fatalf("Got %v expected %v deleting %v", deleted, ok, interval)
+			}
+			delete(expected, interval)
+		} else {
+			intervals.Insert(interval.Low, interval.High, i)
+			expected[interval] = i
+		}
+
+		if err := intervals.Validate(); err != nil {
+			t.Fatalf("Got %v after operation %d", err, i)
+		}
+
+		query := Interval[int]{Low: r.Intn(550), High: 0}
+		query.High = query.Low + r.Intn(20)
+
+		found, values := intervals.Overlapping(query.Low, query.High)
+
+		count := 0
+		for interval := range expected {
+			if interval.Low <= query.High && query.Low <= interval.High {
+				count++
+			}
+		}
+
+		if len(found) != count {
+			t.Fatalf("Got %d intervals expected %d overlapping %v", len(found), count, query)
+		}
+
+		for j, interval := range found {
+			if interval.Low > query.High || query.Low > interval.High || expected[interval] != values[j] {
+				t.Fatalf("Got %v:%v overlapping %v", interval, values[j], query)
+			}
+			if j > 0 && (found[j-1].Low > interval.Low || found[j-1].Low == interval.Low && found[j-1].High >= interval.High) {
+				t.Fatalf("Got %v out of order", found)
+			}
+		}
+	}
+
+	// a corrupted max is reported
+	intervals.tree.Root.Value.max = -1
+	if err := intervals.Validate(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Got %v expected %v", err, ErrInvalid)
+	}
+}
+
+func TestIntervalTreeTime(t *testing.T) {
+
+	start := time.Date(2021, 10, 15, 9, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time {
+		return start.Add(time.Duration(hour) * time.Hour)
+	}
+
+	slots := NewIntervalWith(comparator.TimeComparator)
+	slots.Insert(at(0), at(2), "standup")
+	slots.Insert(at(1), at(3), "review")
+	slots.Insert(at(5), at(6), "deploy")
+
+	var names []interface{}
+
+	slots.Each(func(interval Interval[interface{}], value interface{}) bool {
+		names = append(names, value)
+		return true
+	})
+
+	_, busy := slots.Overlapping(at(2), at(4))
+	_, free := slots.Stabbing(at(4))
+
+	if actualValue, expectedValue := fmt.Sprint(names, busy, free), "[standup review deploy] [standup review] []"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestRedBlackTreeAnyAliases(t *testing.T) {
+
+	var tree *AnyTree = NewWithIntComparator()
+	tree.Insert(2, "b")
+	tree.Insert(1, "a")
+
+	var node *AnyNode = tree.Left()
+	var iterator AnyIterator = tree.Iterator()
+	iterator.Last()
+
+	if actualValue, expectedValue := fmt.Sprint(node.Key, " ", iterator.Value()), "1 b"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
@@ -6,36 +6,145 @@
 package rbt
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 
-	"gorm.io/gorm/utils"
+	"github.com/Jayj1997/go-common/comparator"
 )
 
+// JSON format is an array of {"key": ..., "value": ...} objects in ascending key order,
+// so keys keep their JSON type instead of being turned into object member names.
+
+// entry is the JSON representation of a single node
+type entry[K, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
 // ToJSON outputs the JSON representation of the tree
-func (tree *Tree) ToJSON() ([]byte, error) {
-	elements := make(map[string]interface{})
+func (tree *Tree[K, V]) ToJSON() ([]byte, error) {
+	return tree.MarshalJSON()
+}
+
+// FromJSON populates the tree from the input JSON representation
+func (tree *Tree[K, V]) FromJSON(data []byte) error {
+	return tree.UnmarshalJSON(data)
+}
+
+// MarshalJSON implements json.Marshaler, it outputs the elements as an ordered array of key-value pairs
+func (tree *Tree[K, V]) MarshalJSON() ([]byte, error) {
+	elements := make([]entry[K, V], 0, tree.size)
 
 	it := tree.Iterator()
 
 	for it.Next() {
-		elements[utils.ToString(it.Key())] = it.Value()
+		elements = append(elements, entry[K, V]{Key: it.Key(), Value: it.Value()})
 	}
 
-	return json.Marshal(&elements)
+	return json.Marshal(elements)
 }
 
-// FromJSON populates the tree from the input JSON representation
-func (tree *Tree) FromJSON(data []byte) error {
-	elements := make(map[string]interface{})
+// UnmarshalJSON implements json.Unmarshaler, it replaces the elements of the tree by the decoded ones.
+// Keys are decoded by tree.KeyDecoder if set, otherwise into K by encoding/json.
+// A tree over interface{} keys without comparator, e.g. allocated by encoding/json,
+// gets comparator.Auto and AutoKeyDecoder, other trees need a comparator.
+// On error the tree is left untouched.
+func (tree *Tree[K, V]) UnmarshalJSON(data []byte) (err error) {
+
+	if tree.Comparator == nil {
+		decoder := tree.KeyDecoder
+
+		if err := tree.useAuto(); err != nil {
+			return err
+		}
+
+		defer func() {
+			if err != nil {
+				tree.Comparator, tree.KeyDecoder = nil, decoder
+			}
+		}()
+	}
+
+	var elements []entry[json.RawMessage, V]
+
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
 
-	err := json.Unmarshal(data, &elements)
-	if err != nil {
-		tree.Clear()
+	keys := make([]K, len(elements))
 
-		for key, value := range elements {
-			tree.Insert(key, value)
+	for i, element := range elements {
+
+		key, err := tree.decodeKey(element.Key)
+		if err != nil {
+			return fmt.Errorf("rbt: decode key %s: %w", element.Key, err)
+		}
+
+		if err := tree.CheckKey(key); err != nil {
+			return fmt.Errorf("rbt: decode key %s: %w", element.Key, err)
 		}
+
+		keys[i] = key
+	}
+
+	tree.Clear()
+
+	for i, element := range elements {
+		tree.Insert(keys[i], element.Value)
+	}
+
+	return nil
+}
+
+func (tree *Tree[K, V]) decodeKey(data []byte) (key K, err error) {
+
+	if tree.KeyDecoder != nil {
+		return tree.KeyDecoder(data)
+	}
+
+	err = json.Unmarshal(data, &key)
+
+	return key, err
+}
+
+// KeyDecoderOf returns a key decoder for trees over interface{} keys which decodes keys into T,
+// it should match the comparator's type assertion,
+// e.g. KeyDecoderOf[int64]() for a tree of comparator.Int64Comparator.
+func KeyDecoderOf[T any]() func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var key T
+		err := json.Unmarshal(data, &key)
+		return key, err
+	}
+}
+
+// AutoKeyDecoder returns a key decoder for trees of comparator.Auto,
+// numbers are decoded into json.Number so large integers are not rounded to float64.
+func AutoKeyDecoder() func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var key interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err := decoder.Decode(&key)
+		return key, err
+	}
+}
+
+// useAuto sets comparator.Auto and AutoKeyDecoder on a tree over interface{} keys
+func (tree *Tree[K, V]) useAuto() error {
+
+	compare, ok := interface{}((func(a, b interface{}) int)(comparator.Auto)).(func(a, b K) int)
+	if !ok {
+		return errors.New("rbt: unmarshal into a tree without comparator")
+	}
+
+	tree.Comparator = compare
+
+	if tree.KeyDecoder == nil {
+		tree.KeyDecoder, _ = interface{}(AutoKeyDecoder()).(func(data []byte) (K, error))
 	}
 
-	return err
+	return nil
 }
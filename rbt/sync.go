@@ -0,0 +1,279 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-17 14:05:52
+ * @Description  : concurrency-safe red-black tree
+ */
+package rbt
+
+import (
+	"cmp"
+	"sync"
+
+	"github.com/Jayj1997/go-common/comparator"
+)
+
+// SyncTree is a red-black tree safe for concurrent use by multiple goroutines,
+// all methods are guarded by a sync.RWMutex.
+//
+// The underlying tree runs in persistent mode, so Snapshot and Iterator are O(1)
+// and iterating never holds the lock while writers keep going.
+type SyncTree[K, V any] struct {
+	mu   sync.RWMutex
+	tree *Tree[K, V]
+}
+
+// NewSync instantiates a concurrency-safe red-black tree over an ordered key type
+func NewSync[K cmp.Ordered, V any]() *SyncTree[K, V] {
+	return &SyncTree[K, V]{tree: New[K, V]().Persistent()}
+}
+
+// NewSyncWithFunc instantiates a concurrency-safe red-black tree with a typed compare function
+func NewSyncWithFunc[K, V any](compare func(a, b K) int) *SyncTree[K, V] {
+	return &SyncTree[K, V]{tree: NewWithFunc[K, V](compare).Persistent()}
+}
+
+// NewSyncWith instantiates a concurrency-safe red-black tree with the custom comparator,
+// i.e. keys and values are of type interface{}.
+func NewSyncWith(comparator comparator.Comparator) *SyncTree[interface{}, interface{}] {
+	return &SyncTree[interface{}, interface{}]{tree: NewWith(comparator).Persistent()}
+}
+
+// Insert inserts node into the tree, see Tree.Insert
+func (tree *SyncTree[K, V]) Insert(key K, value V) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	tree.tree.Insert(key, value)
+}
+
+// Get searches the node in the tree by key, see Tree.Get
+func (tree *SyncTree[K, V]) Get(key K) (value V, found bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Get(key)
+}
+
+// Remove remove the node from the tree by key, see Tree.Remove
+func (tree *SyncTree[K, V]) Remove(key K) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	tree.tree.Remove(key)
+}
+
+// CheckKey returns the key type error of key, see Tree.CheckKey
+func (tree *SyncTree[K, V]) CheckKey(key K) error {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.CheckKey(key)
+}
+
+// TryInsert inserts key-value pair into the tree or returns the key type error, see Tree.TryInsert
+func (tree *SyncTree[K, V]) TryInsert(key K, value V) error {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	return tree.tree.TryInsert(key, value)
+}
+
+// TryGet searches the node in the tree by key or returns the key type error, see Tree.TryGet
+func (tree *SyncTree[K, V]) TryGet(key K) (value V, found bool, err error) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.TryGet(key)
+}
+
+// TryRemove removes the node from the tree by key or returns the key type error, see Tree.TryRemove
+func (tree *SyncTree[K, V]) TryRemove(key K) error {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	return tree.tree.TryRemove(key)
+}
+
+// GetOrInsert returns the existing value for the key if present,
+// otherwise it inserts and returns the given value.
+// Second return parameter is true if the value was loaded, false if inserted.
+func (tree *SyncTree[K, V]) GetOrInsert(key K, value V) (actual V, loaded bool) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if actual, loaded = tree.tree.Get(key); loaded {
+		return actual, true
+	}
+
+	tree.tree.Insert(key, value)
+
+	return value, false
+}
+
+// Update atomically replaces the value of key by the result of fn,
+// fn receives the current value and whether key was found,
+// the key is inserted (or overwritten) when fn returns keep as true, otherwise it is removed.
+// fn runs under the write lock so it must not call back into the tree.
+func (tree *SyncTree[K, V]) Update(key K, fn func(old V, found bool) (value V, keep bool)) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	value, keep := fn(tree.tree.Get(key))
+	if keep {
+		tree.tree.Insert(key, value)
+		return
+	}
+
+	tree.tree.Remove(key)
+}
+
+// CompareAndSwap swaps the value of key to new if the current value is equal to old,
+// returns true if the value was swapped. Use SyncTree.CompareAndSwapFunc for values which aren't comparable.
+func CompareAndSwap[K any, V comparable](tree *SyncTree[K, V], key K, old, new V) (swapped bool) {
+	return tree.CompareAndSwapFunc(key, old, new, func(a, b V) bool {
+		return a == b
+	})
+}
+
+// CompareAndSwapFunc swaps the value of key to new if equal reports the current value equal to old,
+// returns true if the value was swapped.
+// equal runs under the write lock so it must not call back into the tree.
+func (tree *SyncTree[K, V]) CompareAndSwapFunc(key K, old, new V, equal func(a, b V) bool) (swapped bool) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	current, found := tree.tree.Get(key)
+	if !found || !equal(current, old) {
+		return false
+	}
+
+	tree.tree.Insert(key, new)
+
+	return true
+}
+
+// Empty returns true if tree does not contain any nodes
+func (tree *SyncTree[K, V]) Empty() bool {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Empty()
+}
+
+// Size returns number of nodes in the tree
+func (tree *SyncTree[K, V]) Size() int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Size()
+}
+
+// Keys returns all keys in-order
+func (tree *SyncTree[K, V]) Keys() []K {
+	return tree.Snapshot().Keys()
+}
+
+// Values returns all values in-order based on the key.
+func (tree *SyncTree[K, V]) Values() []V {
+	return tree.Snapshot().Values()
+}
+
+// Left returns the left-most (min) node or nil if tree is empty
+func (tree *SyncTree[K, V]) Left() *Node[K, V] {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Left()
+}
+
+// Right returns the right-most (max) node or nil if tree is empty
+func (tree *SyncTree[K, V]) Right() *Node[K, V] {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Right()
+}
+
+// Floor finds floor node of the input key, see Tree.Floor
+func (tree *SyncTree[K, V]) Floor(key K) (floor *Node[K, V], found bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Floor(key)
+}
+
+// Ceiling finds ceiling node of the input key, see Tree.Ceiling
+func (tree *SyncTree[K, V]) Ceiling(key K) (ceiling *Node[K, V], found bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Ceiling(key)
+}
+
+// Rank returns the number of keys strictly smaller than key, see Tree.Rank
+func (tree *SyncTree[K, V]) Rank(key K) int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Rank(key)
+}
+
+// Select returns the node holding the k-th smallest key, see Tree.Select
+func (tree *SyncTree[K, V]) Select(k int) (node *Node[K, V], found bool) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.Select(k)
+}
+
+// CountRange returns the number of keys in range [lo, hi), see Tree.CountRange
+func (tree *SyncTree[K, V]) CountRange(lo, hi K) int {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.CountRange(lo, hi)
+}
+
+// Range calls fn on every element between lo and hi in ascending order, see Tree.Range.
+// It walks a snapshot, so fn may call back into the tree.
+func (tree *SyncTree[K, V]) Range(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	tree.Snapshot().Range(lo, hi, loInclusive, hiInclusive, fn)
+}
+
+// ReverseRange calls fn on every element between hi and lo in descending order, see Tree.ReverseRange.
+// It walks a snapshot, so fn may call back into the tree.
+func (tree *SyncTree[K, V]) ReverseRange(lo, hi K, loInclusive, hiInclusive bool, fn func(key K, value V) bool) {
+	tree.Snapshot().ReverseRange(lo, hi, loInclusive, hiInclusive, fn)
+}
+
+// Clear removes all nodes from the tree
+func (tree *SyncTree[K, V]) Clear() {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	tree.tree.Clear()
+}
+
+// Snapshot returns a read-only view of the current version of the tree in O(1)
+func (tree *SyncTree[K, V]) Snapshot() *Snapshot[K, V] {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	return tree.tree.snapshot()
+}
+
+// Iterator returns a stateful iterator over a snapshot of the tree,
+// the lock is only held while taking the snapshot.
+func (tree *SyncTree[K, V]) Iterator() Iterator[K, V] {
+	return tree.Snapshot().Iterator()
+}
+
+// IteratorFrom returns a stateful iterator over a snapshot of the tree positioned right before the ceiling of key
+func (tree *SyncTree[K, V]) IteratorFrom(key K) Iterator[K, V] {
+	return tree.Snapshot().IteratorFrom(key)
+}
+
+// String returns a string representation of container
+func (tree *SyncTree[K, V]) String() string {
+	return tree.Snapshot().String()
+}
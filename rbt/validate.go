@@ -0,0 +1,93 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-28 10:21:37
+ * @Description  : structural invariants of the red-black tree
+ */
+package rbt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalid is wrapped by the errors returned from Validate
+var ErrInvalid = errors.New("rbt: invalid tree")
+
+// Validate checks the red-black tree properties and returns an error describing the first violation:
+// the root is black, no red node has a red child, every path has the same number of black nodes,
+// keys are in strictly ascending order by the comparator, subtree sizes add up,
+// and (unless the tree is persistent, whose nodes are shared) every child points back to its parent.
+// It takes O(n) and is meant for tests and debugging.
+func (tree *Tree[K, V]) Validate() error {
+
+	if nodeColor(tree.Root) != black {
+		return fmt.Errorf("%w: root %v is red", ErrInvalid, tree.Root.Key)
+	}
+
+	if tree.Root != nil && !tree.persistent && tree.Root.Parent != nil {
+		return fmt.Errorf("%w: root %v has a parent", ErrInvalid, tree.Root.Key)
+	}
+
+	var previous *Node[K, V]
+
+	if _, err := tree.validate(tree.Root, &previous); err != nil {
+		return err
+	}
+
+	if size := nodeSize(tree.Root); size != tree.size {
+		return fmt.Errorf("%w: %d nodes, size is %d", ErrInvalid, size, tree.size)
+	}
+
+	return nil
+}
+
+// validate checks the sub-tree rooted at node walking it in-order, previous is the last node visited,
+// it returns the black height of the sub-tree.
+func (tree *Tree[K, V]) validate(node *Node[K, V], previous **Node[K, V]) (blackHeight int, err error) {
+
+	if node == nil {
+		return 1, nil
+	}
+
+	if node.color == red && (nodeColor(node.Left) == red || nodeColor(node.Right) == red) {
+		return 0, fmt.Errorf("%w: red node %v has a red child", ErrInvalid, node.Key)
+	}
+
+	if !tree.persistent {
+		for _, child := range []*Node[K, V]{node.Left, node.Right} {
+			if child != nil && child.Parent != node {
+				return 0, fmt.Errorf("%w: parent of %v is not %v", ErrInvalid, child.Key, node.Key)
+			}
+		}
+	}
+
+	left, err := tree.validate(node.Left, previous)
+	if err != nil {
+		return 0, err
+	}
+
+	if *previous != nil && tree.Comparator((*previous).Key, node.Key) >= 0 {
+		return 0, fmt.Errorf("%w: key %v is not smaller than %v", ErrInvalid, (*previous).Key, node.Key)
+	}
+
+	*previous = node
+
+	right, err := tree.validate(node.Right, previous)
+	if err != nil {
+		return 0, err
+	}
+
+	if left != right {
+		return 0, fmt.Errorf("%w: black height %d left and %d right of %v", ErrInvalid, left, right, node.Key)
+	}
+
+	if size := nodeSize(node.Left) + nodeSize(node.Right) + 1; node.size != size {
+		return 0, fmt.Errorf("%w: size of %v is %d, expected %d", ErrInvalid, node.Key, node.size, size)
+	}
+
+	if node.color == black {
+		left++
+	}
+
+	return left, nil
+}
@@ -0,0 +1,226 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-13 14:36:08
+ * @Description  : sorted map backed by a red-black tree
+ */
+package treemap
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/Jayj1997/go-common/comparator"
+	"github.com/Jayj1997/go-common/rbt"
+)
+
+// Map holds the elements in a red-black tree, iterating in ascending key order.
+// Set algebra walks both maps in order at once, so the maps should be ordered by the same comparator.
+type Map[K, V any] struct {
+	tree *rbt.Tree[K, V]
+}
+
+// New instantiates a tree map over an ordered key type
+func New[K cmp.Ordered, V any]() *Map[K, V] {
+	return &Map[K, V]{tree: rbt.New[K, V]()}
+}
+
+// NewWithFunc instantiates a tree map with a typed compare function
+func NewWithFunc[K, V any](compare func(a, b K) int) *Map[K, V] {
+	return &Map[K, V]{tree: rbt.NewWithFunc[K, V](compare)}
+}
+
+// NewWith instantiates a tree map with the custom comparator,
+// i.e. keys and values are of type interface{}.
+func NewWith(comparator comparator.Comparator) *Map[interface{}, interface{}] {
+	return &Map[interface{}, interface{}]{tree: rbt.NewWith(comparator)}
+}
+
+// NewWithIntComparator instantiates a tree map with IntComparator, i.e. keys are of type int.
+func NewWithIntComparator() *Map[interface{}, interface{}] {
+	return &Map[interface{}, interface{}]{tree: rbt.NewWithIntComparator()}
+}
+
+// NewWithStringComparator instantiates a tree map with StringComparator, i.e. keys are of type string.
+func NewWithStringComparator() *Map[interface{}, interface{}] {
+	return &Map[interface{}, interface{}]{tree: rbt.NewWithStringComparator()}
+}
+
+// Put inserts key-value pair into the map, overwriting the value of an existing key
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Put(key K, value V) {
+	m.tree.Insert(key, value)
+}
+
+// Get searches the element in the map by key and returns its value or zero value if key is not found,
+// Second return parameter is true if key was found, otherwise false
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Get(key K) (value V, found bool) {
+	return m.tree.Get(key)
+}
+
+// Remove removes the element from the map by key
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Remove(key K) {
+	m.tree.Remove(key)
+}
+
+// Contains returns true if key is in the map
+// Key should adhere to the comparator's type assertion, otherwise method panics
+func (m *Map[K, V]) Contains(key K) bool {
+	_, found := m.tree.Get(key)
+	return found
+}
+
+// Empty returns true if map does not contain any elements
+func (m *Map[K, V]) Empty() bool {
+	return m.tree.Empty()
+}
+
+// Size returns number of elements in the map
+func (m *Map[K, V]) Size() int {
+	return m.tree.Size()
+}
+
+// Keys returns all keys in-order
+func (m *Map[K, V]) Keys() []K {
+	return m.tree.Keys()
+}
+
+// Values returns all values in-order based on the key
+func (m *Map[K, V]) Values() []V {
+	return m.tree.Values()
+}
+
+// Clear removes all elements from the map
+func (m *Map[K, V]) Clear() {
+	m.tree.Clear()
+}
+
+// First returns the element of the smallest key,
+// third return parameter is false if the map is empty
+func (m *Map[K, V]) First() (key K, value V, found bool) {
+	if node := m.tree.Left(); node != nil {
+		return node.Key, node.Value, true
+	}
+
+	return key, value, false
+}
+
+// Last returns the element of the largest key,
+// third return parameter is false if the map is empty
+func (m *Map[K, V]) Last() (key K, value V, found bool) {
+	if node := m.tree.Right(); node != nil {
+		return node.Key, node.Value, true
+	}
+
+	return key, value, false
+}
+
+// PollFirst removes and returns the element of the smallest key,
+// third return parameter is false if the map is empty
+func (m *Map[K, V]) PollFirst() (key K, value V, found bool) {
+	if key, value, found = m.First(); found {
+		m.tree.Remove(key)
+	}
+
+	return key, value, found
+}
+
+// PollLast removes and returns the element of the largest key,
+// third return parameter is false if the map is empty
+func (m *Map[K, V]) PollLast() (key K, value V, found bool) {
+	if key, value, found = m.Last(); found {
+		m.tree.Remove(key)
+	}
+
+	return key, value, found
+}
+
+// Iterator returns a stateful iterator whose elements are key/value pairs in ascending key order
+func (m *Map[K, V]) Iterator() rbt.Iterator[K, V] {
+	return m.tree.Iterator()
+}
+
+// Each calls fn on every element in ascending key order until fn returns false
+func (m *Map[K, V]) Each(fn func(key K, value V) bool) {
+	m.tree.Each(fn)
+}
+
+// Union returns a new map holding the elements of both maps,
+// the value in m wins for a key in both.
+func (m *Map[K, V]) Union(other *Map[K, V]) *Map[K, V] {
+	return m.merge(other, true, true, true)
+}
+
+// Intersection returns a new map holding the elements of m whose key is in other too
+func (m *Map[K, V]) Intersection(other *Map[K, V]) *Map[K, V] {
+	return m.merge(other, false, true, false)
+}
+
+// Difference returns a new map holding the elements of m whose key is not in other
+func (m *Map[K, V]) Difference(other *Map[K, V]) *Map[K, V] {
+	return m.merge(other, true, false, false)
+}
+
+// SymmetricDifference returns a new map holding the elements whose key is in only one of the maps
+func (m *Map[K, V]) SymmetricDifference(other *Map[K, V]) *Map[K, V] {
+	return m.merge(other, true, false, true)
+}
+
+// String returns a string representation of container
+func (m *Map[K, V]) String() string {
+	elements := make([]string, 0, m.tree.Size())
+
+	m.tree.Each(func(key K, value V) bool {
+		elements = append(elements, fmt.Sprintf("%v:%v", key, value))
+		return true
+	})
+
+	return "TreeMap\nmap[" + strings.Join(elements, " ") + "]"
+}
+
+// merge walks both maps in ascending key order at once, keeping the elements whose key is
+// only in m, in both (with the value of m) or only in other as told, the result is built in O((n + m)lg(n + m)).
+func (m *Map[K, V]) merge(other *Map[K, V], onlyM, both, onlyOther bool) *Map[K, V] {
+
+	result := &Map[K, V]{tree: rbt.NewWithFunc[K, V](m.tree.Comparator)}
+	result.tree.KeyDecoder = m.tree.KeyDecoder
+
+	left, right := m.tree.Iterator(), other.tree.Iterator()
+	hasLeft, hasRight := left.Next(), right.Next()
+
+	for hasLeft || hasRight {
+
+		var diff int
+
+		switch {
+		case !hasLeft:
+			diff = 1
+		case !hasRight:
+			diff = -1
+		default:
+			diff = m.tree.Comparator(left.Key(), right.Key())
+		}
+
+		switch {
+		case diff < 0:
+			if onlyM {
+				result.tree.Insert(left.Key(), left.Value())
+			}
+			hasLeft = left.Next()
+		case diff > 0:
+			if onlyOther {
+				result.tree.Insert(right.Key(), right.Value())
+			}
+			hasRight = right.Next()
+		default:
+			if both {
+				result.tree.Insert(left.Key(), left.Value())
+			}
+			hasLeft, hasRight = left.Next(), right.Next()
+		}
+	}
+
+	return result
+}
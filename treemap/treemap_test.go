@@ -0,0 +1,215 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-13 15:21:19
+ * @Description  :
+ */
+package treemap
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+
+	m := NewWithIntComparator()
+
+	m.Put(5, "e")
+	m.Put(6, "f")
+	m.Put(7, "g")
+	m.Put(3, "c")
+	m.Put(4, "d")
+	m.Put(1, "x")
+	m.Put(2, "b")
+	m.Put(1, "a") // overwrite
+
+	if actualValue := m.Size(); actualValue != 7 {
+		t.Errorf("Got %v expected %v", actualValue, 7)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(m.Keys(), m.Values()), "[1 2 3 4 5 6 7] [a b c d e f g]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	tests := [][]interface{}{
+		{1, "a", true},
+		{7, "g", true},
+		{8, nil, false},
+	}
+
+	for _, test := range tests {
+		actualValue, actualFound := m.Get(test[0])
+		if actualValue != test[1] || actualFound != test[2] {
+			t.Errorf("Got %v,%v expected %v,%v", actualValue, actualFound, test[1], test[2])
+		}
+		if actualValue := m.Contains(test[0]); actualValue != test[2] {
+			t.Errorf("Got %v expected %v", actualValue, test[2])
+		}
+	}
+
+	m.Remove(4)
+	m.Remove(8)
+
+	if actualValue, expectedValue := m.String(), "TreeMap\nmap[1:a 2:b 3:c 5:e 6:f 7:g]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	m.Clear()
+
+	if actualValue, expectedValue := m.String(), "TreeMap\nmap[]"; !m.Empty() || actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestMapFirstLast(t *testing.T) {
+
+	m := New[string, int]()
+
+	if key, value, found := m.First(); key != "" || value != 0 || found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, "", 0, false)
+	}
+
+	if key, value, found := m.PollLast(); key != "" || value != 0 || found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, "", 0, false)
+	}
+
+	for i, key := range []string{"c", "a", "d", "b"} {
+		m.Put(key, i)
+	}
+
+	if key, value, found := m.First(); key != "a" || value != 1 || !found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, "a", 1, true)
+	}
+
+	if key, value, found := m.Last(); key != "d" || value != 2 || !found {
+		t.Errorf("Got %v,%v,%v expected %v,%v,%v", key, value, found, "d", 2, true)
+	}
+
+	var polled []string
+
+	for !m.Empty() {
+		first, _, _ := m.PollFirst()
+		last, _, _ := m.PollLast()
+		polled = append(polled, first, last)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(polled), "[a d b c]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestMapIterator(t *testing.T) {
+
+	m := NewWithFunc[int, string](func(a, b int) int { return b - a })
+	m.Put(1, "a")
+	m.Put(3, "c")
+	m.Put(2, "b")
+
+	var keys []int
+	it := m.Iterator()
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+
+	var values []string
+	m.Each(func(key int, value string) bool {
+		values = append(values, value)
+		return key != 2
+	})
+
+	if actualValue, expectedValue := fmt.Sprint(keys, values), "[3 2 1] [c b]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestMapSetAlgebra(t *testing.T) {
+
+	a, b := New[int, string](), New[int, string]()
+
+	for _, key := range []int{1, 2, 3, 5} {
+		a.Put(key, fmt.Sprint("a", key))
+	}
+
+	for _, key := range []int{0, 2, 4, 5, 6} {
+		b.Put(key, fmt.Sprint("b", key))
+	}
+
+	tests := []struct {
+		name     string
+		actual   *Map[int, string]
+		expected string
+	}{
+		{"union", a.Union(b), "map[0:b0 1:a1 2:a2 3:a3 4:b4 5:a5 6:b6]"},
+		{"intersection", a.Intersection(b), "map[2:a2 5:a5]"},
+		{"difference", a.Difference(b), "map[1:a1 3:a3]"},
+		{"symmetric difference", a.SymmetricDifference(b), "map[0:b0 1:a1 3:a3 4:b4 6:b6]"},
+		{"union with empty", a.Union(New[int, string]()), "map[1:a1 2:a2 3:a3 5:a5]"},
+		{"intersection with empty", New[int, string]().Intersection(a), "map[]"},
+	}
+
+	for _, test := range tests {
+		if actualValue, expectedValue := test.actual.String(), "TreeMap\n"+test.expected; actualValue != expectedValue {
+			t.Errorf("Got %v expected %v for %v", actualValue, expectedValue, test.name)
+		}
+	}
+
+	// operands are untouched and results are independent
+	union := a.Union(b)
+	union.Put(9, "u9")
+
+	if actualValue, expectedValue := fmt.Sprint(a.Size(), b.Size(), union.Size()), "4 5 8"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestMapSetAlgebraRandom(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	for round := 0; round < 100; round++ {
+
+		a, b := New[int, int](), New[int, int]()
+		inA, inB := map[int]bool{}, map[int]bool{}
+
+		for i := r.Intn(50); i > 0; i-- {
+			key := r.Intn(60)
+			a.Put(key, key)
+			inA[key] = true
+		}
+
+		for i := r.Intn(50); i > 0; i-- {
+			key := r.Intn(60)
+			b.Put(key, key)
+			inB[key] = true
+		}
+
+		expected := func(keep func(a, b bool) bool) string {
+			var keys []int
+			for key := 0; key < 60; key++ {
+				if keep(inA[key], inB[key]) {
+					keys = append(keys, key)
+				}
+			}
+			sort.Ints(keys)
+			return fmt.Sprint(keys)
+		}
+
+		tests := []struct {
+			actual   *Map[int, int]
+			expected string
+		}{
+			{a.Union(b), expected(func(a, b bool) bool { return a || b })},
+			{a.Intersection(b), expected(func(a, b bool) bool { return a && b })},
+			{a.Difference(b), expected(func(a, b bool) bool { return a && !b })},
+			{a.SymmetricDifference(b), expected(func(a, b bool) bool { return a != b })},
+		}
+
+		for i, test := range tests {
+			if actualValue := fmt.Sprint(test.actual.Keys()); actualValue != test.expected {
+				t.Fatalf("Got %v expected %v for operation %d of round %d", actualValue, test.expected, i, round)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-13 16:02:41
+ * @Description  : sorted set backed by a red-black tree
+ */
+package treeset
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/Jayj1997/go-common/comparator"
+	"github.com/Jayj1997/go-common/rbt"
+	"github.com/Jayj1997/go-common/treemap"
+)
+
+// Set holds the items in a red-black tree, iterating in ascending order.
+// Set algebra walks both sets in order at once, so the sets should be ordered by the same comparator.
+type Set[T any] struct {
+	items *treemap.Map[T, struct{}]
+}
+
+// New instantiates a tree set over an ordered item type and adds the items
+func New[T cmp.Ordered](items ...T) *Set[T] {
+	set := &Set[T]{items: treemap.New[T, struct{}]()}
+	set.Add(items...)
+	return set
+}
+
+// NewWithFunc instantiates a tree set with a typed compare function and adds the items
+func NewWithFunc[T any](compare func(a, b T) int, items ...T) *Set[T] {
+	set := &Set[T]{items: treemap.NewWithFunc[T, struct{}](compare)}
+	set.Add(items...)
+	return set
+}
+
+// NewWith instantiates a tree set with the custom comparator and adds the items,
+// i.e. items are of type interface{}.
+func NewWith(comparator comparator.Comparator, items ...interface{}) *Set[interface{}] {
+	return NewWithFunc[interface{}](comparator, items...)
+}
+
+// NewWithIntComparator instantiates a tree set with IntComparator and adds the items, i.e. items are of type int.
+func NewWithIntComparator(items ...interface{}) *Set[interface{}] {
+	return NewWith(comparator.IntComparator, items...)
+}
+
+// NewWithStringComparator instantiates a tree set with StringComparator and adds the items, i.e. items are of type string.
+func NewWithStringComparator(items ...interface{}) *Set[interface{}] {
+	return NewWith(comparator.StringComparator, items...)
+}
+
+// Add adds the items to the set, items already in the set are ignored
+// Items should adhere to the comparator's type assertion, otherwise method panics
+func (set *Set[T]) Add(items ...T) {
+	for _, item := range items {
+		set.items.Put(item, struct{}{})
+	}
+}
+
+// Remove removes the items from the set
+// Items should adhere to the comparator's type assertion, otherwise method panics
+func (set *Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		set.items.Remove(item)
+	}
+}
+
+// Contains returns true if all the items are in the set, it's true for no items
+// Items should adhere to the comparator's type assertion, otherwise method panics
+func (set *Set[T]) Contains(items ...T) bool {
+	for _, item := range items {
+		if !set.items.Contains(item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Empty returns true if set does not contain any items
+func (set *Set[T]) Empty() bool {
+	return set.items.Empty()
+}
+
+// Size returns number of items in the set
+func (set *Set[T]) Size() int {
+	return set.items.Size()
+}
+
+// Values returns all items in-order
+func (set *Set[T]) Values() []T {
+	return set.items.Keys()
+}
+
+// Clear removes all items from the set
+func (set *Set[T]) Clear() {
+	set.items.Clear()
+}
+
+// First returns the smallest item, second return parameter is false if the set is empty
+func (set *Set[T]) First() (item T, found bool) {
+	item, _, found = set.items.First()
+	return item, found
+}
+
+// Last returns the largest item, second return parameter is false if the set is empty
+func (set *Set[T]) Last() (item T, found bool) {
+	item, _, found = set.items.Last()
+	return item, found
+}
+
+// PollFirst removes and returns the smallest item, second return parameter is false if the set is empty
+func (set *Set[T]) PollFirst() (item T, found bool) {
+	item, _, found = set.items.PollFirst()
+	return item, found
+}
+
+// PollLast removes and returns the largest item, second return parameter is false if the set is empty
+func (set *Set[T]) PollLast() (item T, found bool) {
+	item, _, found = set.items.PollLast()
+	return item, found
+}
+
+// Iterator returns a stateful iterator over the items in ascending order, Key() returns the item
+func (set *Set[T]) Iterator() rbt.Iterator[T, struct{}] {
+	return set.items.Iterator()
+}
+
+// Each calls fn on every item in ascending order until fn returns false
+func (set *Set[T]) Each(fn func(item T) bool) {
+	set.items.Each(func(item T, _ struct{}) bool {
+		return fn(item)
+	})
+}
+
+// Union returns a new set holding the items of both sets
+func (set *Set[T]) Union(other *Set[T]) *Set[T] {
+	return &Set[T]{items: set.items.Union(other.items)}
+}
+
+// Intersection returns a new set holding the items in both sets
+func (set *Set[T]) Intersection(other *Set[T]) *Set[T] {
+	return &Set[T]{items: set.items.Intersection(other.items)}
+}
+
+// Difference returns a new set holding the items of set which are not in other
+func (set *Set[T]) Difference(other *Set[T]) *Set[T] {
+	return &Set[T]{items: set.items.Difference(other.items)}
+}
+
+// SymmetricDifference returns a new set holding the items in only one of the sets
+func (set *Set[T]) SymmetricDifference(other *Set[T]) *Set[T] {
+	return &Set[T]{items: set.items.SymmetricDifference(other.items)}
+}
+
+// String returns a string representation of container
+func (set *Set[T]) String() string {
+	items := make([]string, 0, set.Size())
+
+	set.Each(func(item T) bool {
+		items = append(items, fmt.Sprint(item))
+		return true
+	})
+
+	return "TreeSet\n[" + strings.Join(items, " ") + "]"
+}
@@ -0,0 +1,130 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-10-13 16:40:55
+ * @Description  :
+ */
+package treeset
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+
+	set := NewWithIntComparator(3, 1)
+	set.Add()
+	set.Add(2, 1, 5)
+
+	if actualValue := set.Size(); actualValue != 4 {
+		t.Errorf("Got %v expected %v", actualValue, 4)
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(set.Values()), "[1 2 3 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	tests := [][]interface{}{
+		{[]interface{}{}, true},
+		{[]interface{}{1}, true},
+		{[]interface{}{1, 5}, true},
+		{[]interface{}{1, 4}, false},
+	}
+
+	for _, test := range tests {
+		if actualValue := set.Contains(test[0].([]interface{})...); actualValue != test[1] {
+			t.Errorf("Got %v expected %v for %v", actualValue, test[1], test[0])
+		}
+	}
+
+	set.Remove(2, 4)
+
+	if actualValue, expectedValue := set.String(), "TreeSet\n[1 3 5]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	set.Clear()
+
+	if actualValue, expectedValue := set.String(), "TreeSet\n[]"; !set.Empty() || actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestSetFirstLast(t *testing.T) {
+
+	set := New[string]()
+
+	if item, found := set.Last(); item != "" || found {
+		t.Errorf("Got %v,%v expected %v,%v", item, found, "", false)
+	}
+
+	if item, found := set.PollFirst(); item != "" || found {
+		t.Errorf("Got %v,%v expected %v,%v", item, found, "", false)
+	}
+
+	set.Add("b", "d", "a", "c", "e")
+
+	if first, _ := set.First(); first != "a" {
+		t.Errorf("Got %v expected %v", first, "a")
+	}
+
+	if last, _ := set.Last(); last != "e" {
+		t.Errorf("Got %v expected %v", last, "e")
+	}
+
+	first, _ := set.PollFirst()
+	last, _ := set.PollLast()
+
+	if actualValue, expectedValue := fmt.Sprintln(first, last, set.Values()), "a e [b c d]\n"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestSetIterator(t *testing.T) {
+
+	set := NewWithFunc(func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) }, "b", "A", "c", "a")
+
+	var items []string
+	it := set.Iterator()
+	for it.Next() {
+		items = append(items, it.Key())
+	}
+
+	set.Each(func(item string) bool {
+		items = append(items, item)
+		return item != "b"
+	})
+
+	if actualValue, expectedValue := fmt.Sprint(items), "[a b c a b]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+
+	a, b := New(1, 2, 3, 5), New(0, 2, 4, 5, 6)
+
+	tests := []struct {
+		name     string
+		actual   *Set[int]
+		expected string
+	}{
+		{"union", a.Union(b), "[0 1 2 3 4 5 6]"},
+		{"intersection", a.Intersection(b), "[2 5]"},
+		{"difference", a.Difference(b), "[1 3]"},
+		{"reversed difference", b.Difference(a), "[0 4 6]"},
+		{"symmetric difference", a.SymmetricDifference(b), "[0 1 3 4 6]"},
+		{"intersection with empty", a.Intersection(New[int]()), "[]"},
+	}
+
+	for _, test := range tests {
+		if actualValue := fmt.Sprint(test.actual.Values()); actualValue != test.expected {
+			t.Errorf("Got %v expected %v for %v", actualValue, test.expected, test.name)
+		}
+	}
+
+	if actualValue, expectedValue := fmt.Sprint(a.Values(), b.Values()), "[1 2 3 5] [0 2 4 5 6]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
@@ -0,0 +1,593 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-26 10:12:09
+ * @Description  : write-ahead log and crash recovery for trees
+ */
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Jayj1997/go-common/codec"
+)
+
+// Tree is the part of rbt.Tree and btree.Tree (and their SyncTree) used by the log
+type Tree[K, V any] interface {
+	Insert(key K, value V)
+	Remove(key K)
+	Clear()
+	Keys() []K
+	Values() []V
+}
+
+// keyChecker is implemented by the trees which validate keys, like rbt.Tree and btree.Tree,
+// the log checks a key with it before logging it.
+type keyChecker[K any] interface {
+	CheckKey(key K) error
+}
+
+// both files start with a header and hold a sequence of records:
+//
+//	magic "TLOG" | version byte
+//	length uint32 | crc32c of payload uint32 | payload
+//
+// the payload is an operation byte followed by the encoded key and value
+//
+//	insert: key length uvarint | key bytes | value bytes
+//	remove: key bytes
+//	clear:  (empty)
+//
+// the snapshot only holds insert records in key order.
+const (
+	magic   = "TLOG"
+	version = 1
+
+	headerSize = len(magic) + 1
+	frameSize  = 4 + 4
+
+	// maxRecord bounds the length of a record, so a corrupted length doesn't exhaust memory
+	maxRecord = 1 << 30
+
+	snapshotFile = "snapshot"
+	logFile      = "log"
+)
+
+const (
+	opInsert byte = iota + 1
+	opRemove
+	opClear
+)
+
+var (
+	// ErrFormat is returned when a file is not a log of a supported version
+	ErrFormat = errors.New("wal: invalid file format")
+	// ErrClosed is returned when using a Log after Close
+	ErrClosed = errors.New("wal: log is closed")
+
+	table = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// Log records Insert/Remove of a tree into an append-only file before applying them,
+// so the tree can be rebuilt by Open after a crash.
+// Log is safe for concurrent use, operations are applied to the tree in the order they are logged.
+type Log[K, V any] struct {
+	mu      sync.Mutex
+	tree    Tree[K, V]
+	keys    codec.Codec[K]
+	values  codec.Codec[V]
+	dir     string
+	file    *os.File
+	options Options
+}
+
+// Options configures a Log
+type Options struct {
+	// fsync after every record, otherwise records survive a process crash but may be lost on power failure until Sync
+	SyncWrites bool
+}
+
+// Option sets an option of Log
+type Option func(*Options)
+
+// WithSyncWrites makes every operation fsync the log before returning
+func WithSyncWrites() Option {
+	return func(options *Options) {
+		options.SyncWrites = true
+	}
+}
+
+// Open opens or creates the log in directory dir and rebuilds tree from the snapshot and the log,
+// tree is cleared first, and should be changed only through the returned Log afterwards.
+// Keys and values are encoded by the given codecs, gob if nil.
+//
+// The last record of the log cut short, corrupted or zero-filled by a crash while appending is dropped,
+// any other corruption of the log or the snapshot fails with ErrFormat and leaves the files as they are.
+func Open[K, V any](dir string, tree Tree[K, V], keys codec.Codec[K], values codec.Codec[V], options ...Option) (*Log[K, V], error) {
+
+	if keys == nil {
+		keys = codec.Gob[K]()
+	}
+
+	if values == nil {
+		values = codec.Gob[V]()
+	}
+
+	log := &Log[K, V]{tree: tree, keys: keys, values: values, dir: dir}
+
+	for _, option := range options {
+		option(&log.options)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	tree.Clear()
+
+	if _, err := log.replay(filepath.Join(dir, snapshotFile), false); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("wal: replay snapshot: %w", err)
+	}
+
+	valid, err := log.replay(filepath.Join(dir, logFile), true)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("wal: replay log: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// drop the torn tail, or write the header of a new log
+	if valid < int64(headerSize) {
+		err = writeHeader(file)
+	} else {
+		err = file.Truncate(valid)
+	}
+
+	if err == nil {
+		_, err = file.Seek(0, io.SeekEnd)
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	log.file = file
+
+	return log, nil
+}
+
+// Tree returns the tree rebuilt by Open, it should only be read directly.
+// Writes go through the log under its lock, which reads of the tree don't take:
+// unless the tree is safe for concurrent use like rbt.SyncTree, it must not be read
+// while Insert, Remove, Clear or Compact may be running.
+func (log *Log[K, V]) Tree() Tree[K, V] {
+	return log.tree
+}
+
+// Insert logs the insertion of key-value pair and inserts it into the tree,
+// a key the tree rejects, see rbt.Tree.CheckKey, is returned as an error without being logged.
+func (log *Log[K, V]) Insert(key K, value V) error {
+
+	if err := log.checkKey(key); err != nil {
+		return err
+	}
+
+	payload, err := log.encodeInsert([]byte{opInsert}, key, value)
+	if err != nil {
+		return err
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if err := log.append(payload); err != nil {
+		return err
+	}
+
+	log.tree.Insert(key, value)
+
+	return nil
+}
+
+// Remove logs the removal of key and removes it from the tree,
+// a key the tree rejects is returned as an error without being logged.
+func (log *Log[K, V]) Remove(key K) error {
+
+	if err := log.checkKey(key); err != nil {
+		return err
+	}
+
+	encoded, err := log.keys.Encode(key)
+	if err != nil {
+		return fmt.Errorf("wal: encode key %v: %w", key, err)
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if err := log.append(append([]byte{opRemove}, encoded...)); err != nil {
+		return err
+	}
+
+	log.tree.Remove(key)
+
+	return nil
+}
+
+// Clear logs the removal of all keys and clears the tree
+func (log *Log[K, V]) Clear() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if err := log.append([]byte{opClear}); err != nil {
+		return err
+	}
+
+	log.tree.Clear()
+
+	return nil
+}
+
+// Compact writes the current content of the tree as the new snapshot and truncates the log.
+// The snapshot is written to a temporary file and renamed, so a crash leaves either the old or the new one,
+// replaying the old log on the new snapshot gives the same tree.
+func (log *Log[K, V]) Compact() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if log.file == nil {
+		return ErrClosed
+	}
+
+	temporary := filepath.Join(log.dir, snapshotFile+".tmp")
+
+	if err := log.writeSnapshot(temporary); err != nil {
+		os.Remove(temporary)
+		return err
+	}
+
+	if err := os.Rename(temporary, filepath.Join(log.dir, snapshotFile)); err != nil {
+		return err
+	}
+
+	if err := syncDir(log.dir); err != nil {
+		return err
+	}
+
+	if err := log.file.Truncate(int64(headerSize)); err != nil {
+		return err
+	}
+
+	if _, err := log.file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	return log.file.Sync()
+}
+
+// Sync commits the log to stable storage
+func (log *Log[K, V]) Sync() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if log.file == nil {
+		return ErrClosed
+	}
+
+	return log.file.Sync()
+}
+
+// Close syncs and closes the log, the tree stays usable in memory
+func (log *Log[K, V]) Close() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if log.file == nil {
+		return ErrClosed
+	}
+
+	err := log.file.Sync()
+	if closeErr := log.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	log.file = nil
+
+	return err
+}
+
+// append writes a single record with one write call, so a crash leaves at most a torn tail
+func (log *Log[K, V]) append(payload []byte) error {
+
+	if log.file == nil {
+		return ErrClosed
+	}
+
+	if _, err := log.file.Write(frame(payload)); err != nil {
+		return err
+	}
+
+	if log.options.SyncWrites {
+		return log.file.Sync()
+	}
+
+	return nil
+}
+
+func (log *Log[K, V]) writeSnapshot(path string) error {
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+
+	if err := writeHeader(writer); err != nil {
+		return err
+	}
+
+	keys, values := log.tree.Keys(), log.tree.Values()
+
+	for i, key := range keys {
+
+		payload, err := log.encodeInsert([]byte{opInsert}, key, values[i])
+		if err != nil {
+			return err
+		}
+
+		if _, err := writer.Write(frame(payload)); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// replay applies the records of the file at path to the tree,
+// it returns the offset right after the last valid record.
+// A file written in place, i.e. the log, may end with a torn record left by a crash, which is skipped,
+// while the snapshot is renamed into place once complete so it must not.
+func (log *Log[K, V]) replay(path string, torn bool) (valid int64, err error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		// a crash right after creating the file
+		if torn && (err == io.EOF || err == io.ErrUnexpectedEOF) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	if string(header[:len(magic)]) != magic {
+		return 0, fmt.Errorf("%w: bad magic %q", ErrFormat, header[:len(magic)])
+	}
+
+	if header[len(magic)] != version {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrFormat, header[len(magic)])
+	}
+
+	valid = int64(headerSize)
+
+	for {
+		payload, err := readRecord(reader)
+		if err == nil && len(payload) == 0 {
+			err = fmt.Errorf("%w: empty record", ErrFormat)
+		}
+
+		if err == io.EOF {
+			return valid, nil
+		}
+
+		if err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = fmt.Errorf("%w: truncated record", ErrFormat)
+			}
+
+			if !errors.Is(err, ErrFormat) {
+				return valid, err
+			}
+
+			// a crash while appending leaves the last record cut short, corrupted or zero-filled
+			if torn {
+				last, err := lastRecord(file, valid)
+				if err != nil || last {
+					return valid, err
+				}
+			}
+
+			return valid, fmt.Errorf("%w at offset %d", err, valid)
+		}
+
+		if err := log.apply(payload); err != nil {
+			return valid, err
+		}
+
+		valid += int64(frameSize + len(payload))
+	}
+}
+
+// checkKey validates key with the tree if it can, so a key the tree would panic on never reaches the log
+func (log *Log[K, V]) checkKey(key K) error {
+
+	if checker, ok := log.tree.(keyChecker[K]); ok {
+		if err := checker.CheckKey(key); err != nil {
+			return fmt.Errorf("wal: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// lastRecord tells whether the damaged record at offset is the last one of file, i.e. no valid record follows it,
+// like a record cut short, corrupted or zero-filled by a crash while appending it.
+// A damaged length can't tell where the next record starts, so every later offset is tried.
+func lastRecord(file *os.File, offset int64) (bool, error) {
+
+	rest, err := io.ReadAll(io.NewSectionReader(file, offset, math.MaxInt64-offset))
+	if err != nil {
+		return false, err
+	}
+
+	for i := 1; i+frameSize <= len(rest); i++ {
+
+		length := int(binary.BigEndian.Uint32(rest[i:]))
+		if length == 0 || length > len(rest)-i-frameSize {
+			continue
+		}
+
+		payload := rest[i+frameSize : i+frameSize+length]
+		if crc32.Checksum(payload, table) == binary.BigEndian.Uint32(rest[i+4:]) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (log *Log[K, V]) apply(payload []byte) (err error) {
+
+	// a record the tree panics on, e.g. a key of another type, must not make Open panic
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: record rejected by the tree: %v", ErrFormat, recovered)
+		}
+	}()
+
+	if len(payload) == 0 {
+		return fmt.Errorf("%w: empty record", ErrFormat)
+	}
+
+	switch payload[0] {
+	case opInsert:
+		length, n := binary.Uvarint(payload[1:])
+		if n <= 0 || uint64(len(payload)-1-n) < length {
+			return fmt.Errorf("%w: truncated insert record", ErrFormat)
+		}
+
+		data := payload[1+n:]
+
+		key, err := log.keys.Decode(data[:length])
+		if err != nil {
+			return fmt.Errorf("wal: decode key: %w", err)
+		}
+
+		value, err := log.values.Decode(data[length:])
+		if err != nil {
+			return fmt.Errorf("wal: decode value of key %v: %w", key, err)
+		}
+
+		log.tree.Insert(key, value)
+
+	case opRemove:
+		key, err := log.keys.Decode(payload[1:])
+		if err != nil {
+			return fmt.Errorf("wal: decode key: %w", err)
+		}
+
+		log.tree.Remove(key)
+
+	case opClear:
+		log.tree.Clear()
+
+	default:
+		return fmt.Errorf("%w: unknown operation %d", ErrFormat, payload[0])
+	}
+
+	return nil
+}
+
+func (log *Log[K, V]) encodeInsert(payload []byte, key K, value V) ([]byte, error) {
+
+	encodedKey, err := log.keys.Encode(key)
+	if err != nil {
+		return nil, fmt.Errorf("wal: encode key %v: %w", key, err)
+	}
+
+	encodedValue, err := log.values.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("wal: encode value of key %v: %w", key, err)
+	}
+
+	payload = binary.AppendUvarint(payload, uint64(len(encodedKey)))
+	payload = append(payload, encodedKey...)
+
+	return append(payload, encodedValue...), nil
+}
+
+// frame prefixes payload with its length and checksum
+func frame(payload []byte) []byte {
+
+	record := make([]byte, 0, frameSize+len(payload))
+	record = binary.BigEndian.AppendUint32(record, uint32(len(payload)))
+	record = binary.BigEndian.AppendUint32(record, crc32.Checksum(payload, table))
+
+	return append(record, payload...)
+}
+
+func readRecord(reader io.Reader) ([]byte, error) {
+
+	header := make([]byte, frameSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header)
+	if length > maxRecord {
+		return nil, fmt.Errorf("%w: record too large", ErrFormat)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+
+	if crc32.Checksum(payload, table) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrFormat)
+	}
+
+	return payload, nil
+}
+
+func writeHeader(writer io.Writer) error {
+	_, err := writer.Write(append([]byte(magic), version))
+	return err
+}
+
+// syncDir commits a rename in dir to stable storage
+func syncDir(dir string) error {
+
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
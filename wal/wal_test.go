@@ -0,0 +1,266 @@
+/*
+ * @Author       : jayj
+ * @Date         : 2021-09-26 14:30:52
+ * @Description  :
+ */
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jayj1997/go-common/btree"
+	"github.com/Jayj1997/go-common/codec"
+	"github.com/Jayj1997/go-common/comparator"
+	"github.com/Jayj1997/go-common/rbt"
+)
+
+func TestLogReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := Open[int, string](dir, rbt.New[int, string](), codec.Int, codec.String)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	for i := 1; i <= 10; i++ {
+		log.Insert(i, fmt.Sprint(i))
+	}
+	log.Remove(3)
+	log.Insert(5, "five")
+	if err := log.Close(); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if err := log.Insert(11, "11"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Got %v expected %v", err, ErrClosed)
+	}
+
+	// replay into a B-tree
+	tree := btree.New[int, string](3)
+	log, err = Open[int, string](dir, tree, codec.Int, codec.String)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	defer log.Close()
+
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 4 5 6 7 8 9 10]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, _ := tree.Get(5); actualValue != "five" {
+		t.Errorf("Got %v expected %v", actualValue, "five")
+	}
+}
+
+func TestLogTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := Open[interface{}, interface{}](dir, rbt.NewWithIntComparator(), nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Insert(1, "a")
+	log.Insert(2, "b")
+	log.Insert(3, "c")
+	log.Close()
+
+	// a crash in the middle of the last record
+	path := filepath.Join(dir, logFile)
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := rbt.NewWithIntComparator()
+	log, err = Open[interface{}, interface{}](dir, tree, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// the torn tail is dropped, so new records are not hidden behind it
+	log.Insert(4, "d")
+	log.Close()
+
+	tree = rbt.NewWithIntComparator()
+	log, err = Open[interface{}, interface{}](dir, tree, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Close()
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 4]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// flip a byte of the last record, its checksum no longer matches
+	data, _ := os.ReadFile(path)
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	os.WriteFile(path, corrupted, 0644)
+
+	tree = rbt.NewWithIntComparator()
+	log, err = Open[interface{}, interface{}](dir, tree, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Insert(4, "d")
+	log.Close()
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 4]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// a crash after the file was extended leaves zeros
+	data, _ = os.ReadFile(path)
+	os.WriteFile(path, append(append([]byte{}, data...), make([]byte, 4096)...), 0644)
+
+	tree = rbt.NewWithIntComparator()
+	log, err = Open[interface{}, interface{}](dir, tree, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Close()
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1 2 4]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+	if actualValue, _ := os.ReadFile(path); len(actualValue) != len(data) {
+		t.Errorf("Got %v expected %v", len(actualValue), len(data))
+	}
+
+	// flip a byte of a record in the middle, the log is corrupted rather than torn
+	for _, offset := range []int{headerSize + frameSize, headerSize + 1} {
+		corrupted := append([]byte{}, data...)
+		corrupted[offset] ^= 0xff
+		os.WriteFile(path, corrupted, 0644)
+
+		if _, err := Open[interface{}, interface{}](dir, rbt.NewWithIntComparator(), nil, nil); !errors.Is(err, ErrFormat) {
+			t.Errorf("Got %v expected %v", err, ErrFormat)
+		}
+
+		// the records after the corruption are not truncated away
+		if actualValue, _ := os.ReadFile(path); len(actualValue) != len(data) {
+			t.Errorf("Got %v expected %v", len(actualValue), len(data))
+		}
+	}
+}
+
+func TestLogCompact(t *testing.T) {
+	dir := t.TempDir()
+
+	tree := rbt.NewSyncWith(comparator.StringComparator)
+	log, err := Open[interface{}, interface{}](dir, tree, nil, nil, WithSyncWrites())
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		log.Insert(fmt.Sprint(i%10), i)
+	}
+	oldLog, _ := os.ReadFile(filepath.Join(dir, logFile))
+
+	if err := log.Compact(); err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, logFile)); info.Size() != int64(headerSize) {
+		t.Errorf("Got %v expected %v", info.Size(), headerSize)
+	}
+
+	log.Remove("0")
+	log.Clear()
+	log.Insert("x", 1)
+	log.Insert("y", 2)
+	log.Remove("x")
+	log.Close()
+
+	reopened := rbt.NewWith(comparator.StringComparator)
+	log, err = Open[interface{}, interface{}](dir, reopened, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	if actualValue, expectedValue := fmt.Sprint(reopened.Keys(), reopened.Values()), "[y] [2]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// compact then crash before truncating the log, replaying it again on the snapshot gives the same tree
+	for i := 0; i < 10; i++ {
+		log.Insert(fmt.Sprint(i), i)
+	}
+	log.Compact()
+	log.Close()
+	os.WriteFile(filepath.Join(dir, logFile), oldLog, 0644)
+
+	reopened = rbt.NewWith(comparator.StringComparator)
+	log, err = Open[interface{}, interface{}](dir, reopened, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	defer log.Close()
+	if actualValue, expectedValue := fmt.Sprint(reopened.Values()), "[90 91 92 93 94 95 96 97 98 99 2]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+}
+
+func TestLogInvalid(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, logFile), []byte("JSON{}"), 0644)
+
+	if _, err := Open[int, int](dir, rbt.New[int, int](), codec.Int, codec.Int); !errors.Is(err, ErrFormat) {
+		t.Errorf("Got %v expected %v", err, ErrFormat)
+	}
+
+	// the snapshot is complete once renamed, so even a short last record is corrupted
+	dir = t.TempDir()
+	log, err := Open[int, int](dir, rbt.New[int, int](), codec.Int, codec.Int)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Insert(1, 1)
+	log.Insert(2, 2)
+	log.Compact()
+	log.Close()
+
+	path := filepath.Join(dir, snapshotFile)
+	data, _ := os.ReadFile(path)
+	for _, corrupted := range [][]byte{data[:len(data)-1], data[:headerSize-1]} {
+		os.WriteFile(path, corrupted, 0644)
+
+		if _, err := Open[int, int](dir, rbt.New[int, int](), codec.Int, codec.Int); !errors.Is(err, ErrFormat) {
+			t.Errorf("Got %v expected %v", err, ErrFormat)
+		}
+	}
+}
+
+func TestLogKeyType(t *testing.T) {
+	dir := t.TempDir()
+
+	log, err := Open[interface{}, interface{}](dir, rbt.NewSyncWith(comparator.IntComparator), nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Insert(1, "a")
+
+	// rejected keys are not logged
+	if err := log.Insert("b", "b"); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+	if err := log.Remove("b"); !errors.Is(err, comparator.ErrKeyType) {
+		t.Errorf("Got %v expected %v", err, comparator.ErrKeyType)
+	}
+	log.Close()
+
+	tree := rbt.NewWithIntComparator()
+	log, err = Open[interface{}, interface{}](dir, tree, nil, nil)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+	log.Close()
+	if actualValue, expectedValue := fmt.Sprint(tree.Keys()), "[1]"; actualValue != expectedValue {
+		t.Errorf("Got %v expected %v", actualValue, expectedValue)
+	}
+
+	// a log of int keys replayed into a tree of string keys
+	if _, err := Open[interface{}, interface{}](dir, rbt.NewWithStringComparator(), nil, nil); !errors.Is(err, ErrFormat) {
+		t.Errorf("Got %v expected %v", err, ErrFormat)
+	}
+}